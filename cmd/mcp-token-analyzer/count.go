@@ -0,0 +1,311 @@
+// count.go contains the count command, which counts the tokens of files and
+// stdin without connecting to any server.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/aquasecurity/table"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/term"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// countStdin is the file argument of the count command that reads stdin.
+const countStdin = "-"
+
+// countInput is a file (or stdin) to count the tokens of.
+type countInput struct {
+	name string
+	data []byte
+}
+
+// countResult holds the token counts of an input with each model. Inputs
+// holding tool definitions are counted per tool; others as plain text.
+type countResult struct {
+	name      string
+	tools     []*mcp.Tool             // Tool definitions in the input; nil for plain text
+	totals    []int                   // Total tokens per model
+	toolStats [][]analyzer.ToolTokens // Stats of tools per model, index-aligned with tools
+}
+
+// runCount counts the tokens of the files matching the count command's
+// arguments, or of stdin, with each of the selected tokenizer models.
+func runCount() error {
+	models := *flagCountModels
+	if len(models) == 0 {
+		models = []string{*flagTokenizerModel}
+	}
+	counters := make([]*analyzer.TokenCounter, len(models))
+	for i, model := range models {
+		counter, err := analyzer.NewTokenCounter(model)
+		if err != nil {
+			return fmt.Errorf("failed to create tokenizer for model %s: %w", model, err)
+		}
+		counters[i] = counter
+	}
+
+	inputs, err := readCountInputs(*argCountFiles)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*countResult, len(inputs))
+	for i, in := range inputs {
+		results[i], err = countTokens(in, counters)
+		if err != nil {
+			return err
+		}
+	}
+
+	renderCountTools(results, models)
+	renderCountSummary(results, models)
+	return nil
+}
+
+// readCountInputs reads the files matching patterns, in order. Patterns are
+// globs; "-", or no patterns at all, reads stdin. Since stdin can only be
+// read once, "-" may only be given once.
+func readCountInputs(patterns []string) ([]countInput, error) {
+	if len(patterns) == 0 {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, errors.New("nothing to count: pass files or globs, or pipe text to stdin")
+		}
+		patterns = []string{countStdin}
+	}
+	var stdinArgs int
+	for _, pattern := range patterns {
+		if isCountStdin(pattern) {
+			stdinArgs++
+		}
+	}
+	if stdinArgs > 1 {
+		return nil, fmt.Errorf("%q can only be given once: stdin is read only once", countStdin)
+	}
+
+	var inputs []countInput
+	for _, pattern := range patterns {
+		if isCountStdin(pattern) {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stdin: %w", err)
+			}
+			inputs = append(inputs, countInput{name: "stdin", data: data})
+			continue
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		if len(paths) == 0 {
+			// Not a glob, or a glob without matches: let reading the
+			// pattern itself report why there is nothing to count.
+			paths = []string{pattern}
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			inputs = append(inputs, countInput{name: path, data: data})
+		}
+	}
+	return inputs, nil
+}
+
+// isCountStdin reports whether a count argument reads stdin. kingpin passes
+// a bare "-" argument on as an empty string.
+func isCountStdin(pattern string) bool {
+	return pattern == countStdin || pattern == ""
+}
+
+// countTokens counts the tokens of in with each counter: per tool and field
+// if it holds tool definitions, or as a whole otherwise.
+func countTokens(in countInput, counters []*analyzer.TokenCounter) (*countResult, error) {
+	result := &countResult{name: in.name, totals: make([]int, len(counters))}
+	result.tools = parseToolDefinitions(in.data)
+
+	for i, counter := range counters {
+		if result.tools == nil {
+			result.totals[i] = counter.CountTokens(string(in.data))
+			continue
+		}
+
+		stats := make([]analyzer.ToolTokens, len(result.tools))
+		for j, tool := range result.tools {
+			s, err := counter.AnalyzeTool(tool)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze tool %s in %s: %w", tool.Name, in.name, err)
+			}
+			stats[j] = s
+			result.totals[i] += s.TotalTokens
+		}
+		result.toolStats = append(result.toolStats, stats)
+	}
+	return result, nil
+}
+
+// parseToolDefinitions returns the tool definitions in data, which may be a
+// single tool, an array of tools or a tools/list result. It returns nil if
+// data is anything else, including JSON that does not look like tools: every
+// tool needs a name and an input schema.
+func parseToolDefinitions(data []byte) []*mcp.Tool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var (
+		tools []*mcp.Tool
+		list  struct {
+			Tools []*mcp.Tool `json:"tools"`
+		}
+		tool mcp.Tool
+	)
+	switch {
+	case data[0] == '[':
+		if json.Unmarshal(data, &tools) != nil {
+			return nil
+		}
+	case json.Unmarshal(data, &list) == nil && list.Tools != nil:
+		tools = list.Tools
+	case json.Unmarshal(data, &tool) == nil:
+		tools = []*mcp.Tool{&tool}
+	}
+
+	if len(tools) == 0 || slices.ContainsFunc(tools, func(t *mcp.Tool) bool {
+		return t == nil || t.Name == "" || t.InputSchema == nil
+	}) {
+		return nil
+	}
+	return tools
+}
+
+// renderCountSummary renders the token count of each input, with a column
+// per model.
+func renderCountSummary(results []*countResult, models []string) {
+	fmt.Println("\nToken Count")
+	t := table.New(os.Stdout)
+
+	headers := []string{"File", "Type", "Tokens"}
+	if len(models) > 1 {
+		headers = headers[:2]
+		for _, model := range models {
+			headers = append(headers, "Tokens ("+model+")")
+		}
+	}
+	t.SetHeaders(headers...)
+
+	totals := make([]int, len(models))
+	for _, r := range results {
+		kind := "text"
+		switch {
+		case len(r.tools) == 1:
+			kind = "1 tool"
+		case r.tools != nil:
+			kind = printer.Sprintf("%d tools", len(r.tools))
+		}
+		row := []string{r.name, kind}
+		for i, total := range r.totals {
+			row = append(row, printer.Sprintf("%d", total))
+			totals[i] += total
+		}
+		t.AddRow(row...)
+	}
+
+	footers := []string{tableLabelTotal, ""}
+	for _, total := range totals {
+		footers = append(footers, printer.Sprintf("%d", total))
+	}
+	t.AddFooters(footers...)
+	t.Render()
+
+	renderCountContextUsage(totals, models)
+}
+
+// renderCountContextUsage renders the context usage of the total of each
+// model, labeled with the model when counting with several.
+func renderCountContextUsage(totals []int, models []string) {
+	if len(models) == 1 {
+		renderContextUsage(totals[0])
+		return
+	}
+	if *flagContextLimit <= 0 {
+		return
+	}
+
+	fmt.Println()
+	for i, model := range models {
+		pct := float64(totals[i]) / float64(*flagContextLimit) * 100
+		fmt.Printf("Context Usage (%s): %s / %s (%.1f%%)\n",
+			model,
+			printer.Sprintf("%d", totals[i]),
+			printer.Sprintf("%d", *flagContextLimit),
+			pct,
+		)
+	}
+}
+
+// renderCountTools renders the per-field breakdown of the tool definitions
+// found in results, sorted by total tokens, with a Model column when
+// counting with several models.
+func renderCountTools(results []*countResult, models []string) {
+	type toolRow struct {
+		file  string
+		model string
+		stats analyzer.ToolTokens
+	}
+	var rows []toolRow
+	for _, r := range results {
+		for i, stats := range r.toolStats {
+			for _, s := range stats {
+				rows = append(rows, toolRow{file: r.name, model: models[i], stats: s})
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(rows, func(a, b toolRow) int {
+		return b.stats.TotalTokens - a.stats.TotalTokens
+	})
+
+	showModel := len(models) > 1
+	headers := []string{"File", "Tool"}
+	if showModel {
+		headers = append(headers, "Model")
+	}
+	headers = append(headers, "Name", "Desc", "Schema", "Output", "Annot.", "Total")
+
+	fmt.Println("\nTool Analysis (sorted by total tokens)")
+	t := table.New(os.Stdout)
+	t.SetHeaders(headers...)
+	for _, r := range rows {
+		row := []string{r.file, r.stats.Name}
+		if showModel {
+			row = append(row, r.model)
+		}
+		row = append(row,
+			strconv.Itoa(r.stats.NameTokens),
+			strconv.Itoa(r.stats.DescTokens),
+			strconv.Itoa(r.stats.SchemaTokens),
+			strconv.Itoa(r.stats.OutputSchemaTokens),
+			strconv.Itoa(r.stats.AnnotationsTokens),
+			strconv.Itoa(r.stats.TotalTokens),
+		)
+		t.AddRow(row...)
+	}
+	t.Render()
+}
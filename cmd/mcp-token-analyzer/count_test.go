@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseToolDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{name: "single tool", data: `{"name":"a","inputSchema":{"type":"object"}}`, want: []string{"a"}},
+		{name: "array", data: ` [{"name":"a","inputSchema":{}},{"name":"b","inputSchema":{}}]`, want: []string{"a", "b"}},
+		{name: "tools/list result", data: `{"tools":[{"name":"a","inputSchema":{}}],"nextCursor":"x"}`, want: []string{"a"}},
+		{name: "plain text", data: "Draft instructions", want: nil},
+		{name: "empty", data: "\n", want: nil},
+		{name: "other json", data: `{"foo":1}`, want: nil},
+		{name: "tool without schema", data: `{"name":"a","description":"no schema"}`, want: nil},
+		{name: "array with non-tool", data: `[{"name":"a","inputSchema":{}},{"foo":1}]`, want: nil},
+		{name: "empty array", data: `[]`, want: nil},
+		{name: "invalid json", data: `[{"name":`, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := parseToolDefinitions([]byte(tt.data))
+			var got []string
+			for _, tool := range tools {
+				got = append(got, tool.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected tools %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadCountInputs(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"a.json": "{}", "b.json": "[]", "c.md": "text"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inputs, err := readCountInputs([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "c.md")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, in := range inputs {
+		names = append(names, filepath.Base(in.name))
+	}
+	if want := []string{"a.json", "b.json", "c.md"}; !slices.Equal(names, want) {
+		t.Errorf("expected inputs %v, got %v", want, names)
+	}
+	if string(inputs[2].data) != "text" {
+		t.Errorf("expected c.md to read %q, got %q", "text", inputs[2].data)
+	}
+
+	for _, pattern := range []string{filepath.Join(dir, "missing.txt"), filepath.Join(dir, "*.txt"), "["} {
+		if _, err := readCountInputs([]string{pattern}); err == nil {
+			t.Errorf("expected an error for %q", pattern)
+		}
+	}
+
+	if _, err := readCountInputs([]string{"-", filepath.Join(dir, "c.md"), "-"}); err == nil {
+		t.Error("expected an error for reading stdin twice")
+	}
+}
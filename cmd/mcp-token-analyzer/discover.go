@@ -0,0 +1,110 @@
+// discover.go contains --discover mode, which finds and analyzes the MCP
+// configs of every installed client.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+// clientResult holds the analysis results for all servers loaded by a single
+// MCP client, which may be spread over several config files.
+type clientResult struct {
+	Client string
+	Paths  []string
+	Report analyzer.Report // Results of the servers in all config files
+	Errors []error         // Config load/validation errors
+}
+
+// runDiscover finds every client config under the discovery home directory
+// (and workspace configs in the project directory), analyzes each one, and
+// reports per-client totals, with cost estimates if est is not nil.
+func runDiscover(ctx context.Context, a *analyzer.Analyzer, est *cost.Estimator, resolveInput config.InputResolver) error {
+	home := *flagDiscoverHome
+	if home == "" {
+		var err error
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to determine home directory: %w", err)
+		}
+	}
+
+	workspace := *flagProject
+	if workspace == "" {
+		var err error
+		workspace, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to determine project directory: %w", err)
+		}
+	}
+
+	found := config.Discover(home, workspace)
+	if len(found) == 0 {
+		return fmt.Errorf("no MCP client configs found under %s", home)
+	}
+
+	var (
+		clients  []*clientResult
+		byClient = make(map[string]*clientResult)
+	)
+	for _, dc := range found {
+		cr, ok := byClient[dc.Client]
+		if !ok {
+			cr = &clientResult{Client: dc.Client}
+			byClient[dc.Client] = cr
+			clients = append(clients, cr)
+		}
+		cr.Paths = append(cr.Paths, dc.Path)
+
+		err := dc.Err
+		if err == nil && len(dc.Config.MergedServers()) == 0 {
+			// Common for files like ~/.claude.json or Zed settings that
+			// exist for other reasons; nothing to analyze.
+			continue
+		}
+		if err == nil {
+			err = prepareConfig(dc.Config, resolveInput)
+		}
+		if err != nil {
+			cr.Errors = append(cr.Errors, fmt.Errorf("%s: %w", dc.Path, err))
+			continue
+		}
+
+		report := a.Analyze(ctx, dc.Config.MergedServers())
+		cr.Report.Servers = append(cr.Report.Servers, report.Servers...)
+
+		if *flagDetail {
+			renderDetailTables(report.Servers, nil)
+		}
+		renderSummary(fmt.Sprintf("%s: %s", dc.Client, dc.Path), report.Servers, nil, est)
+	}
+
+	// A client loads the servers of all its config files together, so
+	// their tools collide across files.
+	for _, cr := range clients {
+		renderCollisions("Tool Collisions: "+cr.Client, cr.Report.Collisions())
+	}
+	renderClientSummary(clients, est)
+
+	var (
+		failCount, serverCount int
+		errs                   []error
+	)
+	for _, cr := range clients {
+		failCount += cr.Report.Failures()
+		serverCount += len(cr.Report.Servers)
+		errs = append(errs, cr.Errors...)
+	}
+	if failCount > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d servers failed analysis", failCount, serverCount))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,241 @@
+// explain.go contains the explain command, which shows how the text of a
+// component or of stdin is split into tokens.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aquasecurity/table"
+	"golang.org/x/term"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// ANSI escape sequences for the alternating backgrounds of explained tokens.
+var explainColors = []string{"\x1b[30;46m", "\x1b[30;43m"}
+
+// explainSeparator separates tokens when output is not colored.
+const explainSeparator = "|"
+
+// explainField is a piece of text that is counted as a whole.
+type explainField struct {
+	label  string
+	text   string
+	tokens []analyzer.Token
+}
+
+// explainsStdin reports whether the explain command reads its text from
+// stdin rather than from a server.
+func explainsStdin() bool {
+	return *argExplainTarget == "" || *argExplainTarget == "-"
+}
+
+// runExplainStdin explains the text read from stdin.
+func runExplainStdin(counter *analyzer.TokenCounter) error {
+	if *argExplainTarget == "" && term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("nothing to explain: name a component as server/name, or pipe text to stdin")
+	}
+
+	text, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	fields := []*explainField{{label: fieldText, text: string(text)}}
+	renderExplanation("stdin", counter, fields)
+	return nil
+}
+
+// runExplain explains the fields of the component named by the explain
+// target: server/name, or just the name when a single server is selected.
+// Tools, prompts and resources are looked up in that order.
+func runExplain(ctx context.Context, servers map[string]*config.ServerConfig, a *analyzer.Analyzer) error {
+	serverName, name, ok := strings.Cut(*argExplainTarget, "/")
+	if !ok {
+		if len(servers) != 1 {
+			return fmt.Errorf("%d servers selected; name the component as server/%s", len(servers), *argExplainTarget)
+		}
+		name = serverName
+		for s := range servers {
+			serverName = s
+		}
+	}
+
+	srv, ok := servers[serverName]
+	if !ok {
+		return fmt.Errorf("server %q not found in config", serverName)
+	}
+
+	result := a.AnalyzeServerConfig(ctx, serverName, srv)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	title, fields, err := componentFields(result, name)
+	if err != nil {
+		return err
+	}
+	renderExplanation(title, a.Counter(), fields)
+	return nil
+}
+
+// componentFields returns the fields of the named component of result, as
+// the exact text whose tokens the analysis counts.
+func componentFields(result *analyzer.ServerResult, name string) (string, []*explainField, error) {
+	var (
+		fields []*explainField
+		errs   []error
+	)
+	add := func(label, text string) {
+		fields = append(fields, &explainField{label: label, text: text})
+	}
+	addJSON := func(label string, v any) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to marshal %s: %w", label, err))
+			return
+		}
+		add(label, string(b))
+	}
+
+	for _, tool := range result.Tools {
+		if tool.Name != name {
+			continue
+		}
+		add(fieldName, tool.Name)
+		add(fieldDescription, tool.Description)
+		addJSON(fieldInputSchema, tool.InputSchema)
+		if tool.OutputSchema != nil {
+			addJSON(fieldOutputSchema, tool.OutputSchema)
+		}
+		if tool.Annotations != nil {
+			addJSON(fieldAnnotations, tool.Annotations)
+		}
+		return fmt.Sprintf("Tool %s/%s", result.Name, name), fields, errors.Join(errs...)
+	}
+
+	for _, prompt := range result.Prompts {
+		if prompt.Name != name {
+			continue
+		}
+		add(fieldName, prompt.Name)
+		add(fieldDescription, prompt.Description)
+		addJSON(fieldArguments, prompt.Arguments)
+		return fmt.Sprintf("Prompt %s/%s", result.Name, name), fields, errors.Join(errs...)
+	}
+
+	for _, res := range result.Resources {
+		if res.Name != name {
+			continue
+		}
+		add(fieldName, res.Name)
+		add(fieldURI, res.URI)
+		add(fieldDescription, res.Description)
+		return fmt.Sprintf("Resource %s/%s", result.Name, name), fields, nil
+	}
+	for _, tmpl := range result.ResourceTemplates {
+		if tmpl.Name != name {
+			continue
+		}
+		add(fieldName, tmpl.Name)
+		add(fieldURI, tmpl.URITemplate)
+		add(fieldDescription, tmpl.Description)
+		return fmt.Sprintf("Resource template %s/%s", result.Name, name), fields, nil
+	}
+
+	return "", nil, fmt.Errorf("server %q has no tool, prompt or resource named %q", result.Name, name)
+}
+
+// renderExplanation tokenizes fields and prints each of them with its token
+// boundaries and ids, followed by the words that cost the most tokens.
+func renderExplanation(title string, counter *analyzer.TokenCounter, fields []*explainField) {
+	var (
+		total int
+		texts [][]analyzer.Token
+	)
+	for _, f := range fields {
+		f.tokens = counter.Tokens(f.text)
+		total += len(f.tokens)
+		texts = append(texts, f.tokens)
+	}
+	fmt.Printf("%s: %s tokens (tokenizer model %s)\n", title, printer.Sprintf("%d", total), *flagTokenizerModel)
+
+	for _, f := range fields {
+		if len(f.tokens) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%s tokens)\n", f.label, printer.Sprintf("%d", len(f.tokens)))
+		text, ids := formatTokens(f.tokens, highlightChanges)
+		fmt.Println(text)
+		fmt.Printf("Token IDs: %s\n", ids)
+	}
+
+	words := analyzer.ExpensiveWords(*flagExplainTop, texts...)
+	if len(words) == 0 {
+		return
+	}
+	fmt.Println()
+	t := table.New(os.Stdout)
+	t.SetHeaders("Word", "Tokens", "Chars", "Occurrences")
+	t.SetAlignment(table.AlignLeft, table.AlignRight, table.AlignRight, table.AlignRight)
+	for _, w := range words {
+		t.AddRow(
+			w.Word,
+			strconv.Itoa(w.Tokens),
+			strconv.Itoa(utf8.RuneCountInString(w.Word)),
+			strconv.Itoa(w.Occurrences),
+		)
+	}
+	t.Render()
+}
+
+// formatTokens formats the text of tokens with the boundaries between them
+// marked, and the token ids. With color, tokens get alternating backgrounds
+// and each id the background of its token; without, tokens are separated by
+// explainSeparator. Tokens that end inside a multi-byte character are
+// merged with the following ones, and newlines are shown as ↵.
+func formatTokens(tokens []analyzer.Token, color bool) (string, string) {
+	var (
+		text, ids strings.Builder
+		segment   string
+		n         int // Index of the segment, for alternating colors
+	)
+	for i, tok := range tokens {
+		if i > 0 {
+			ids.WriteByte(' ')
+		}
+		id := strconv.Itoa(tok.ID)
+		if color {
+			id = explainColors[n%len(explainColors)] + id + ansiReset
+		}
+		ids.WriteString(id)
+
+		segment += tok.Text
+		if !utf8.ValidString(segment) && i < len(tokens)-1 {
+			continue
+		}
+
+		segment = strings.ReplaceAll(segment, "\n", "↵\n")
+		switch {
+		case color:
+			text.WriteString(explainColors[n%len(explainColors)] + segment + ansiReset)
+		case n > 0:
+			text.WriteString(explainSeparator + segment)
+		default:
+			text.WriteString(segment)
+		}
+		segment = ""
+		n++
+	}
+	return text.String(), ids.String()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []analyzer.Token
+		color    bool
+		wantText string
+		wantIDs  string
+	}{
+		{
+			name:     "empty",
+			wantText: "",
+			wantIDs:  "",
+		},
+		{
+			name:     "separated",
+			tokens:   []analyzer.Token{{ID: 1, Text: "get"}, {ID: 2, Text: "_user"}, {ID: 3, Text: " by"}},
+			wantText: "get|_user| by",
+			wantIDs:  "1 2 3",
+		},
+		{
+			name:     "newlines",
+			tokens:   []analyzer.Token{{ID: 1, Text: "one"}, {ID: 2, Text: "\n"}, {ID: 3, Text: "two"}},
+			wantText: "one|↵\n|two",
+			wantIDs:  "1 2 3",
+		},
+		{
+			name:     "split multi-byte character",
+			tokens:   []analyzer.Token{{ID: 1, Text: "\xf0\x9f"}, {ID: 2, Text: "\x8e\x89"}, {ID: 3, Text: "!"}},
+			wantText: "🎉|!",
+			wantIDs:  "1 2 3",
+		},
+		{
+			name:     "colored",
+			tokens:   []analyzer.Token{{ID: 1, Text: "a"}, {ID: 2, Text: "\xc3"}, {ID: 3, Text: "\xa9"}, {ID: 4, Text: "b"}},
+			color:    true,
+			wantText: explainColors[0] + "a" + ansiReset + explainColors[1] + "é" + ansiReset + explainColors[0] + "b" + ansiReset,
+			wantIDs: explainColors[0] + "1" + ansiReset + " " + explainColors[1] + "2" + ansiReset + " " +
+				explainColors[1] + "3" + ansiReset + " " + explainColors[0] + "4" + ansiReset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ids := formatTokens(tt.tokens, tt.color)
+			if text != tt.wantText {
+				t.Errorf("expected text %q, got %q", tt.wantText, text)
+			}
+			if ids != tt.wantIDs {
+				t.Errorf("expected ids %q, got %q", tt.wantIDs, ids)
+			}
+		})
+	}
+}
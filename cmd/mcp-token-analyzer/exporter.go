@@ -0,0 +1,247 @@
+// exporter.go contains the exporter command, which periodically analyzes the
+// configured servers and serves the results as Prometheus metrics.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+var (
+	descTokens = prometheus.NewDesc(
+		"mcp_tokens",
+		"Tokens in the definition of an MCP server component; instructions have an empty component label.",
+		[]string{"server", "component", "kind", "tokenizer"}, nil,
+	)
+	descServerTokens = prometheus.NewDesc(
+		"mcp_server_tokens",
+		"Tokens in all components of an MCP server.",
+		[]string{"server", "tokenizer"}, nil,
+	)
+	descServerEffectiveTokens = prometheus.NewDesc(
+		"mcp_server_effective_tokens",
+		"Tokens the client sends to the model for an MCP server, after disabled servers and filtered tools are left out.",
+		[]string{"server", "tokenizer"}, nil,
+	)
+	descServerCost = prometheus.NewDesc(
+		"mcp_server_estimated_cost_dollars",
+		"Estimated cost in USD of sending the effective definitions of an MCP server with every request for a day or a month, from the --cost.* pricing and usage; only reported with --cost.pricing.",
+		[]string{"server", "model", "period"}, nil,
+	)
+	descServerUp = prometheus.NewDesc(
+		"mcp_server_up",
+		"Whether the last analysis of an MCP server succeeded.",
+		[]string{"server"}, nil,
+	)
+	descServerHandshake = prometheus.NewDesc(
+		"mcp_server_handshake_duration_seconds",
+		"Time taken to connect to and initialize an MCP server in the last analysis, including retries.",
+		[]string{"server"}, nil,
+	)
+	descServerRetries = prometheus.NewDesc(
+		"mcp_server_retries",
+		"Retried connection attempts and list requests in the last analysis of an MCP server.",
+		[]string{"server"}, nil,
+	)
+	descServerLastSuccess = prometheus.NewDesc(
+		"mcp_server_last_success_timestamp_seconds",
+		"Unix time of the last successful analysis of an MCP server.",
+		[]string{"server"}, nil,
+	)
+	descRefreshDuration = prometheus.NewDesc(
+		"mcp_token_analyzer_refresh_duration_seconds",
+		"Time taken by the last analysis of all servers.",
+		nil, nil,
+	)
+	descLastRefresh = prometheus.NewDesc(
+		"mcp_token_analyzer_last_refresh_timestamp_seconds",
+		"Unix time the last analysis of all servers finished.",
+		nil, nil,
+	)
+)
+
+// exporter is a prometheus.Collector reporting the results of the latest
+// analysis. Metrics are generated from the latest report on each scrape, so
+// tools and servers that disappear stop being reported.
+type exporter struct {
+	analyzer  *analyzer.Analyzer
+	servers   map[string]*config.ServerConfig
+	tokenizer string
+	estimator *cost.Estimator // nil unless cost estimation is enabled
+
+	mu              sync.Mutex
+	report          *analyzer.Report // nil until the first refresh
+	refreshed       time.Time
+	refreshDuration time.Duration
+	lastSuccess     map[string]time.Time // By server name
+}
+
+func newExporter(a *analyzer.Analyzer, servers map[string]*config.ServerConfig, tokenizer string, estimator *cost.Estimator) *exporter {
+	return &exporter{
+		analyzer:    a,
+		servers:     servers,
+		tokenizer:   tokenizer,
+		estimator:   estimator,
+		lastSuccess: make(map[string]time.Time),
+	}
+}
+
+// refresh analyzes all servers and replaces the reported results.
+func (e *exporter) refresh(ctx context.Context) {
+	start := time.Now()
+	report := e.analyzer.Analyze(ctx, e.servers)
+	e.update(report, start, time.Since(start))
+
+	fmt.Fprintf(os.Stderr, "Analyzed %d servers (%d failed) in %s\n",
+		len(report.Servers), report.Failures(), time.Since(start).Round(time.Millisecond))
+}
+
+// update replaces the reported results with report, which was started at
+// start and took duration.
+func (e *exporter) update(report *analyzer.Report, start time.Time, duration time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.report = report
+	e.refreshed = start.Add(duration)
+	e.refreshDuration = duration
+	for _, r := range report.Servers {
+		if r.Error == nil {
+			e.lastSuccess[r.Name] = e.refreshed
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (e *exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		descTokens, descServerTokens, descServerEffectiveTokens, descServerCost, descServerUp, descServerHandshake,
+		descServerRetries, descServerLastSuccess, descRefreshDuration, descLastRefresh,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (e *exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.report == nil {
+		return
+	}
+
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	gauge(descRefreshDuration, e.refreshDuration.Seconds())
+	gauge(descLastRefresh, float64(e.refreshed.UnixMilli())/1000)
+
+	for _, r := range e.report.Servers {
+		if last, ok := e.lastSuccess[r.Name]; ok {
+			gauge(descServerLastSuccess, float64(last.UnixMilli())/1000, r.Name)
+		}
+		gauge(descServerRetries, float64(r.Retries), r.Name)
+
+		if r.Error != nil {
+			gauge(descServerUp, 0, r.Name)
+			continue
+		}
+		gauge(descServerUp, 1, r.Name)
+		gauge(descServerHandshake, r.ConnectDuration.Seconds(), r.Name)
+		gauge(descServerTokens, float64(r.TotalTokens()), r.Name, e.tokenizer)
+		gauge(descServerEffectiveTokens, float64(r.EffectiveTokens()), r.Name, e.tokenizer)
+		if e.estimator != nil {
+			gauge(descServerCost, e.estimator.Daily(r.EffectiveTokens()), r.Name, e.estimator.Model, "day")
+			gauge(descServerCost, e.estimator.Monthly(r.EffectiveTokens()), r.Name, e.estimator.Model, "month")
+		}
+
+		// Components that share a name (e.g. a resource and a template)
+		// would produce duplicate series, so their counts are summed.
+		components := make(map[[2]string]int)
+		components[[2]string{"", analyzer.KindInstructions}] = r.InstructionTokens
+		for _, t := range r.ToolStats {
+			components[[2]string{t.Name, analyzer.KindTool}] += t.TotalTokens
+		}
+		for _, p := range r.PromptStats {
+			components[[2]string{p.Name, analyzer.KindPrompt}] += p.TotalTokens
+		}
+		for _, res := range r.ResourceStats {
+			components[[2]string{res.Name, analyzer.KindResource}] += res.TotalTokens
+		}
+		for key, tokens := range components {
+			gauge(descTokens, float64(tokens), r.Name, key[0], key[1], e.tokenizer)
+		}
+	}
+}
+
+// runExporter analyzes the servers every --exporter.refresh-interval and
+// serves the results on --exporter.listen-address until ctx is done. Cost
+// metrics are exported if est is not nil.
+func runExporter(ctx context.Context, servers map[string]*config.ServerConfig, a *analyzer.Analyzer, est *cost.Estimator) error {
+	if *flagExporterInterval <= 0 {
+		return errors.New("--exporter.refresh-interval must be positive")
+	}
+
+	e := newExporter(a, servers, *flagTokenizerModel, est)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		e,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	// Listen before the first analysis, which may take a while, so that
+	// address errors are reported right away.
+	ln, err := net.Listen("tcp", *flagExporterAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(*flagExporterPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving metrics on %s%s\n", ln.Addr(), *flagExporterPath)
+
+	ticker := time.NewTicker(*flagExporterInterval)
+	defer ticker.Stop()
+
+	e.refresh(ctx)
+	for {
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("failed to serve metrics: %w", err)
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		case <-ticker.C:
+			e.refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+func TestExporter_Collect(t *testing.T) {
+	e := newExporter(nil, nil, "gpt-4", nil)
+	if n := testutil.CollectAndCount(e); n != 0 {
+		t.Errorf("expected no metrics before the first refresh, got %d", n)
+	}
+
+	start := time.Unix(1700000000, 0)
+	e.update(&analyzer.Report{
+		Servers: []*analyzer.ServerResult{
+			{
+				Name:                "github",
+				InstructionTokens:   10,
+				TotalToolTokens:     analyzer.ToolTokens{TotalTokens: 300},
+				EffectiveToolTokens: analyzer.ToolTokens{TotalTokens: 100},
+				ToolStats: []analyzer.ToolTokens{
+					{Name: "search", TotalTokens: 100},
+					{Name: "create_issue", TotalTokens: 200},
+				},
+				ExcludedTools:   map[string]bool{"create_issue": true},
+				ConnectDuration: 250 * time.Millisecond,
+			},
+			{Name: "broken", Error: errors.New("connection refused"), Retries: 2},
+		},
+	}, start, 2*time.Second)
+
+	expected := `
+# HELP mcp_server_effective_tokens Tokens the client sends to the model for an MCP server, after disabled servers and filtered tools are left out.
+# TYPE mcp_server_effective_tokens gauge
+mcp_server_effective_tokens{server="github",tokenizer="gpt-4"} 110
+# HELP mcp_server_handshake_duration_seconds Time taken to connect to and initialize an MCP server in the last analysis, including retries.
+# TYPE mcp_server_handshake_duration_seconds gauge
+mcp_server_handshake_duration_seconds{server="github"} 0.25
+# HELP mcp_server_last_success_timestamp_seconds Unix time of the last successful analysis of an MCP server.
+# TYPE mcp_server_last_success_timestamp_seconds gauge
+mcp_server_last_success_timestamp_seconds{server="github"} 1.700000002e+09
+# HELP mcp_server_retries Retried connection attempts and list requests in the last analysis of an MCP server.
+# TYPE mcp_server_retries gauge
+mcp_server_retries{server="broken"} 2
+mcp_server_retries{server="github"} 0
+# HELP mcp_server_tokens Tokens in all components of an MCP server.
+# TYPE mcp_server_tokens gauge
+mcp_server_tokens{server="github",tokenizer="gpt-4"} 310
+# HELP mcp_server_up Whether the last analysis of an MCP server succeeded.
+# TYPE mcp_server_up gauge
+mcp_server_up{server="broken"} 0
+mcp_server_up{server="github"} 1
+# HELP mcp_token_analyzer_last_refresh_timestamp_seconds Unix time the last analysis of all servers finished.
+# TYPE mcp_token_analyzer_last_refresh_timestamp_seconds gauge
+mcp_token_analyzer_last_refresh_timestamp_seconds 1.700000002e+09
+# HELP mcp_token_analyzer_refresh_duration_seconds Time taken by the last analysis of all servers.
+# TYPE mcp_token_analyzer_refresh_duration_seconds gauge
+mcp_token_analyzer_refresh_duration_seconds 2
+# HELP mcp_tokens Tokens in the definition of an MCP server component; instructions have an empty component label.
+# TYPE mcp_tokens gauge
+mcp_tokens{component="",kind="instructions",server="github",tokenizer="gpt-4"} 10
+mcp_tokens{component="create_issue",kind="tool",server="github",tokenizer="gpt-4"} 200
+mcp_tokens{component="search",kind="tool",server="github",tokenizer="gpt-4"} 100
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// A later failure keeps reporting the last success.
+	e.update(&analyzer.Report{
+		Servers: []*analyzer.ServerResult{{Name: "github", Error: errors.New("timeout")}},
+	}, start.Add(time.Hour), time.Second)
+
+	expected = `
+# HELP mcp_server_last_success_timestamp_seconds Unix time of the last successful analysis of an MCP server.
+# TYPE mcp_server_last_success_timestamp_seconds gauge
+mcp_server_last_success_timestamp_seconds{server="github"} 1.700000002e+09
+# HELP mcp_server_up Whether the last analysis of an MCP server succeeded.
+# TYPE mcp_server_up gauge
+mcp_server_up{server="github"} 0
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(expected), "mcp_server_last_success_timestamp_seconds", "mcp_server_up"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExporter_CollectCost(t *testing.T) {
+	estimator, err := cost.NewEstimator(cost.Pricing{"gpt-4o": {Input: 2.5, CachedInput: 1.25}}, "gpt-4o", cost.Usage{RequestsPerDay: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newExporter(nil, nil, "gpt-4", estimator)
+	e.update(&analyzer.Report{
+		Servers: []*analyzer.ServerResult{
+			{Name: "github", TotalToolTokens: analyzer.ToolTokens{TotalTokens: 4000}, EffectiveToolTokens: analyzer.ToolTokens{TotalTokens: 2000}},
+			{Name: "broken", Error: errors.New("connection refused")},
+		},
+	}, time.Unix(1700000000, 0), time.Second)
+
+	expected := `
+# HELP mcp_server_estimated_cost_dollars Estimated cost in USD of sending the effective definitions of an MCP server with every request for a day or a month, from the --cost.* pricing and usage; only reported with --cost.pricing.
+# TYPE mcp_server_estimated_cost_dollars gauge
+mcp_server_estimated_cost_dollars{model="gpt-4o",period="day",server="github"} 5
+mcp_server_estimated_cost_dollars{model="gpt-4o",period="month",server="github"} 150
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(expected), "mcp_server_estimated_cost_dollars"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExporter_Registers(t *testing.T) {
+	if err := prometheus.NewRegistry().Register(newExporter(nil, nil, "gpt-4", nil)); err != nil {
+		t.Errorf("failed to register exporter: %v", err)
+	}
+}
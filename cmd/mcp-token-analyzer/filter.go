@@ -0,0 +1,107 @@
+// filter.go contains the tool filter used to compute the effective token
+// footprint: the tools a client actually sends to the model once disabled
+// servers, client allow/deny lists, VS Code tool sets and the
+// --include-tool/--exclude-tool flags are taken into account.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// toolFilter selects the tools that count toward the effective footprint, on
+// top of the per-server lists in the client config.
+type toolFilter struct {
+	include []string // Glob patterns; empty includes every tool
+	exclude []string // Glob patterns
+
+	// When useToolSets is set, only tools matching one of toolSetRefs (the
+	// references of the selected VS Code tool sets) are included.
+	useToolSets bool
+	toolSetRefs []string
+}
+
+// newToolFilter builds a toolFilter from the --include-tool, --exclude-tool,
+// --toolsets and --toolset flags.
+func newToolFilter() (*toolFilter, error) {
+	f := &toolFilter{include: *flagIncludeTools, exclude: *flagExcludeTools}
+
+	for _, pattern := range slices.Concat(f.include, f.exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+
+	if len(*flagToolSets) > 0 {
+		if *flagToolSetsFile == "" {
+			return nil, errors.New("--toolset requires --toolsets")
+		}
+		sets, err := config.LoadToolSets(*flagToolSetsFile)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := config.ResolveToolSets(sets, *flagToolSets)
+		if err != nil {
+			return nil, err
+		}
+		f.useToolSets, f.toolSetRefs = true, refs
+	}
+
+	return f, nil
+}
+
+// matchToolPattern reports whether a glob pattern matches a tool. Patterns
+// containing a slash are matched against "server/tool"; others against the
+// bare tool name.
+func matchToolPattern(pattern, server, tool string) bool {
+	name := tool
+	if strings.Contains(pattern, "/") {
+		name = server + "/" + tool
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// allows reports whether the filter lets the given tool of the given server
+// through. It is used as analyzer.Options.ToolFilter.
+func (f *toolFilter) allows(server, tool string) bool {
+	if f.useToolSets {
+		var inSet bool
+		for _, ref := range f.toolSetRefs {
+			if config.ToolRefMatches(ref, server, tool) {
+				inSet = true
+				break
+			}
+		}
+		if !inSet {
+			return false
+		}
+	}
+
+	if len(f.include) > 0 {
+		var included bool
+		for _, pattern := range f.include {
+			if matchToolPattern(pattern, server, tool) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, pattern := range f.exclude {
+		if matchToolPattern(pattern, server, tool) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestToolFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       toolFilter
+		server, tool string
+		want         bool
+	}{
+		{"empty_filter", toolFilter{}, "github", "search", true},
+		{"include_match", toolFilter{include: []string{"search*"}}, "github", "search_code", true},
+		{"include_no_match", toolFilter{include: []string{"get_*"}}, "github", "search_code", false},
+		{"exclude_match", toolFilter{exclude: []string{"*_code"}}, "github", "search_code", false},
+		{"exclude_wins_over_include", toolFilter{include: []string{"*"}, exclude: []string{"search_code"}}, "github", "search_code", false},
+		{"server_pattern_match", toolFilter{exclude: []string{"github/*"}}, "github", "search_code", false},
+		{"server_pattern_other_server", toolFilter{exclude: []string{"github/*"}}, "gitlab", "search_code", true},
+		{"tool_set_match", toolFilter{useToolSets: true, toolSetRefs: []string{"github/search_code"}}, "github", "search_code", true},
+		{"tool_set_no_match", toolFilter{useToolSets: true, toolSetRefs: []string{"fetch"}}, "github", "search_code", false},
+		{"empty_tool_set", toolFilter{useToolSets: true}, "github", "search_code", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.allows(tt.server, tt.tool); got != tt.want {
+				t.Errorf("allows(%q, %q) = %v, want %v", tt.server, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// history.go contains the recording of analysis runs in the --history.dir
+// store and the history command, which reports trends from it.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aquasecurity/table"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/history"
+)
+
+// historyTimeFormat is used for run times in history tables.
+const historyTimeFormat = "2006-01-02 15:04"
+
+// recordRun appends the results of an analysis run to the --history.dir
+// store. It does nothing if no store is configured.
+func recordRun(report *analyzer.Report) error {
+	if *flagHistoryDir == "" {
+		return nil
+	}
+
+	run := history.NewRun(report, *flagTokenizerModel, time.Now())
+	if err := history.NewStore(*flagHistoryDir).Append(run); err != nil {
+		return fmt.Errorf("failed to record run in history: %w", err)
+	}
+	return nil
+}
+
+// runHistory prints the token trend of every server and the biggest growing
+// components over the --since window. Only runs made with the
+// --tokenizer.model tokenizer are compared, since counts from different
+// tokenizers differ.
+func runHistory() error {
+	if *flagHistoryDir == "" {
+		return errors.New("history requires --history.dir")
+	}
+
+	since := time.Now().Add(-*flagHistorySince)
+	runs, err := history.NewStore(*flagHistoryDir).Runs(since)
+	if err != nil {
+		return err
+	}
+	runs = slices.DeleteFunc(runs, func(r history.Run) bool {
+		return r.Tokenizer != *flagTokenizerModel
+	})
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs with tokenizer %s recorded in %s since %s", *flagTokenizerModel, *flagHistoryDir, since.Format(historyTimeFormat))
+	}
+
+	renderTrends(fmt.Sprintf("Token Trends (%d runs since %s)", len(runs), since.Format(historyTimeFormat)), history.Trends(runs))
+	renderGrowers(history.Growers(runs, *flagHistoryTop))
+
+	return nil
+}
+
+// renderTrends renders the trend of each server's total tokens.
+func renderTrends(title string, trends []history.Trend) {
+	fmt.Println("\n" + title)
+	t := table.New(os.Stdout)
+	t.SetHeaders("MCP Server", "Runs", "First Run", "Version", "Tokens", "Last Run", "Version", "Tokens", "Change")
+
+	for _, tr := range trends {
+		t.AddRow(
+			tr.Server,
+			strconv.Itoa(tr.Runs),
+			tr.First.Local().Format(historyTimeFormat),
+			tr.FirstVersion,
+			printer.Sprintf("%d", tr.FirstTokens),
+			tr.Last.Local().Format(historyTimeFormat),
+			tr.LastVersion,
+			printer.Sprintf("%d", tr.LastTokens),
+			printer.Sprintf("%+d", tr.Delta()),
+		)
+	}
+	t.Render()
+}
+
+// renderGrowers renders the components whose token count grew the most.
+func renderGrowers(growers []history.Growth) {
+	fmt.Println("\nBiggest Growers")
+	if len(growers) == 0 {
+		fmt.Println("No component grew in this window.")
+		return
+	}
+
+	t := table.New(os.Stdout)
+	t.SetHeaders("MCP Server", "Component", "Name", "From", "To", "Change")
+	for _, g := range growers {
+		t.AddRow(
+			g.Server,
+			g.Kind,
+			g.Name,
+			printer.Sprintf("%d", g.From),
+			printer.Sprintf("%d", g.To),
+			printer.Sprintf("%+d", g.Delta()),
+		)
+	}
+	t.Render()
+}
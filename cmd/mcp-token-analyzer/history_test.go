@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/history"
+)
+
+func TestRecordRun(t *testing.T) {
+	dir := t.TempDir()
+	prev := *flagHistoryDir
+	*flagHistoryDir = dir
+	t.Cleanup(func() { *flagHistoryDir = prev })
+
+	report := &analyzer.Report{
+		Servers: []*analyzer.ServerResult{
+			{Name: "github", InstructionTokens: 10, ToolStats: []analyzer.ToolTokens{{Name: "search", TotalTokens: 100}}},
+		},
+	}
+	for range 2 {
+		if err := recordRun(report); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	runs, err := history.NewStore(dir).Runs(time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Tokenizer != *flagTokenizerModel || runs[0].Servers[0].Tools["search"] != 100 {
+		t.Errorf("unexpected recorded runs: %+v", runs)
+	}
+}
+
+func TestRecordRun_Disabled(t *testing.T) {
+	prev := *flagHistoryDir
+	*flagHistoryDir = ""
+	t.Cleanup(func() { *flagHistoryDir = prev })
+
+	if err := recordRun(&analyzer.Report{}); err != nil {
+		t.Errorf("expected no error without --history.dir, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// inputs.go contains resolution of VS Code ${input:id} placeholders from an
+// inputs file or interactive prompts.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// newInputResolver returns a config.InputResolver that looks up input values
+// in the given inputs file (if any) and otherwise prompts on the terminal.
+// When stdin is not a terminal, unresolved inputs are left for
+// config.Interpolate to report.
+func newInputResolver(inputsFile string) (config.InputResolver, error) {
+	var fileInputs map[string]string
+	if inputsFile != "" {
+		var err error
+		fileInputs, err = config.LoadInputsFile(inputsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	stdin := bufio.NewReader(os.Stdin)
+
+	return func(input config.InputConfig) (string, bool, error) {
+		if v, ok := fileInputs[input.ID]; ok {
+			return v, true, nil
+		}
+		if !interactive {
+			return "", false, nil
+		}
+		return promptInput(stdin, input)
+	}, nil
+}
+
+// promptInput asks for an input value on stderr. Password inputs are read
+// without echo. An empty answer selects the input's default, if it has one.
+func promptInput(stdin *bufio.Reader, input config.InputConfig) (string, bool, error) {
+	label := input.Description
+	if label == "" {
+		label = input.ID
+	}
+	if input.Default != "" && !input.Password {
+		label += fmt.Sprintf(" [%s]", input.Default)
+	}
+	fmt.Fprintf(os.Stderr, "%s (input:%s): ", label, input.ID)
+
+	var answer string
+	if input.Password {
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read input: %w", err)
+		}
+		answer = string(b)
+	} else {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", false, fmt.Errorf("failed to read input: %w", err)
+		}
+		answer = strings.TrimRight(line, "\r\n")
+	}
+
+	if answer == "" {
+		return "", false, nil
+	}
+	return answer, true, nil
+}
@@ -1,114 +1,241 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
-	"sync"
 	"syscall"
 
 	"github.com/alecthomas/kingpin/v2"
-	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
 	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
 	"github.com/tjhop/mcp-token-analyzer/pkg/mcpclient"
 )
 
 const (
-	tableLabelTotal          = "TOTAL"
-	maxConcurrentConnections = 10
-	unknownServerName        = "<unknown>"
+	tableLabelTotal  = analyzer.TotalLabel
+	configFormatAuto = "auto"
 )
 
 var (
-	supportedMCPTransports = []string{string(config.TransportStdio), string(config.TransportHTTP), string(config.TransportStreamableHTTP)}
+	supportedConfigFormats = []string{configFormatAuto, string(config.FormatMCPJSON), string(config.FormatZed), string(config.FormatGoose), string(config.FormatCodex)}
+	supportedMCPTransports = []string{string(config.TransportStdio), string(config.TransportHTTP), string(config.TransportStreamableHTTP), string(config.TransportSSE)}
 
 	// Flags for ad-hoc connections to individual MCP servers.
-	flagMCPTransport   = kingpin.Flag("mcp.transport", "Transport to use (stdio, http, streamable-http)").Short('t').Default("stdio").Enum(supportedMCPTransports...)
+	flagMCPTransport   = kingpin.Flag("mcp.transport", "Transport to use (stdio, http, streamable-http, sse)").Short('t').Default("stdio").Enum(supportedMCPTransports...)
 	flagMCPCommand     = kingpin.Flag("mcp.command", "Command to run (for stdio transport)").Short('c').String()
-	flagMCPURL         = kingpin.Flag("mcp.url", "URL to connect to (for http transport)").Short('u').String()
-	flagTokenizerModel = kingpin.Flag("tokenizer.model", "Tokenizer model to use (e.g. gpt-4, gpt-3.5-turbo)").Short('m').Default("gpt-4").String()
-	// TODO (@tjhop): add `--tokenizer.list` flag to list available tokenizers/models and exit.
+	flagMCPURL         = kingpin.Flag("mcp.url", "URL to connect to (for http and sse transports)").Short('u').String()
+	flagMCPSSEFallback = kingpin.Flag("mcp.sse-fallback", "Retry http servers with the legacy SSE transport if they reject the initialize request").Bool()
+	flagMCPCwd         = kingpin.Flag("mcp.cwd", "Working directory for the server process (for stdio transport)").String()
+
+	// Timeouts apply to ad-hoc servers and to config servers that don't set
+	// startupTimeout/requestTimeout themselves.
+	flagMCPStartupTimeout = kingpin.Flag("mcp.startup-timeout", "Maximum time for a server to start and initialize before it is shut down and reported as timed out").Default("60s").Duration()
+	flagMCPRequestTimeout = kingpin.Flag("mcp.request-timeout", "Maximum time for each HTTP request and each listing of tools, prompts or resources").Default("30s").Duration()
+	flagTokenizerModel    = kingpin.Flag("tokenizer.model", "Tokenizer model to use (e.g. gpt-4, gpt-3.5-turbo)").Short('m').Default("gpt-4").String()
+	flagTokenizerList     = kingpin.Flag("tokenizer.list", "List the model and encoding names --tokenizer.model accepts, with their encoding, vocab size, backend and whether they are available offline, and exit").Bool()
+
+	// Flags for server process management.
+	flagServerLogs = kingpin.Flag("server-logs", "Directory to write the full stderr output of each stdio server to, one <server>.log file per server").String()
+
+	// Flags for retrying transient failures (refused connections, rate
+	// limiting, gateway errors) when connecting and listing components.
+	flagRetryAttempts   = kingpin.Flag("retry.attempts", "Maximum attempts for connecting to a server and for each list request; 1 disables retries").Default("3").Int()
+	flagRetryBackoff    = kingpin.Flag("retry.backoff", "Delay before the first retry, doubled for each further retry").Default("500ms").Duration()
+	flagRetryMaxBackoff = kingpin.Flag("retry.max-backoff", "Maximum delay between retries").Default("10s").Duration()
+	flagRetryJitter     = kingpin.Flag("retry.jitter", "Fraction by which to randomize each retry delay (0-1)").Default("0.2").Float64()
 
 	// Flags for working with mcp.json config files.
 	// Allows for connecting to multiple MCP servers at once.
-	flagConfigFile   = kingpin.Flag("config", "Path to mcp.json config file").Short('f').String()
+	flagConfigFiles  = kingpin.Flag("config", "Path to mcp.json config file; repeat to merge several files, with later files taking precedence for servers of the same name").Short('f').Strings()
+	flagConfigFormat = kingpin.Flag("config.format", "Config file format (auto, mcp.json, zed, goose, codex); auto detects from the file extension and contents").Default(configFormatAuto).Enum(supportedConfigFormats...)
+	flagProject      = kingpin.Flag("config.project", "Project directory for Claude Code configs: selects its local-scoped servers in ~/.claude.json and loads its .mcp.json (defaults to the current directory for .claude.json files)").String()
+	flagInputsFile   = kingpin.Flag("inputs", "Path to JSON file mapping ${input:id} placeholder IDs to values").String()
 	flagServer       = kingpin.Flag("server", "Analyze only this named server from config").Short('s').String()
 	flagDetail       = kingpin.Flag("detail", "Show detailed per-server tables").Bool()
 	flagContextLimit = kingpin.Flag("limit", "Optional context window limit for percentage calculation").Int()
-)
-
-// ServerResult holds the analysis results for a single MCP server.
-type ServerResult struct {
-	Name                string
-	Error               error
-	InstructionTokens   int
-	TotalToolTokens     analyzer.ToolTokens
-	TotalPromptTokens   analyzer.PromptTokens
-	TotalResourceTokens analyzer.ResourceTokens
-
-	// Per-component stats for --detail mode
-	ToolStats     []analyzer.ToolTokens
-	PromptStats   []analyzer.PromptTokens
-	ResourceStats []analyzer.ResourceTokens
-}
-
-// TotalTokens returns the grand total of all tokens for this server.
-func (r *ServerResult) TotalTokens() int {
-	return r.InstructionTokens + r.TotalToolTokens.TotalTokens + r.TotalPromptTokens.TotalTokens + r.TotalResourceTokens.TotalTokens
-}
 
-// logAnalysisError prints an error message for a failed component analysis.
-func logAnalysisError(componentType, name string, err error) {
-	fmt.Fprintf(os.Stderr, "Error analyzing %s %s: %v\n", componentType, name, err)
-}
-
-// resolveServerName determines the display name for a server, preferring
-// the configured name over the server-reported name.
-func resolveServerName(configuredName string, serverInfo *mcp.Implementation) string {
-	switch {
-	case configuredName != "":
-		return configuredName
-	case serverInfo != nil && serverInfo.Name != "":
-		return serverInfo.Name
-	default:
-		return unknownServerName
-	}
-}
+	// Flags for what-if analysis of the effective footprint, applied on top
+	// of the tool lists in the client config.
+	flagIncludeTools = kingpin.Flag("include-tool", "Only count tools matching this glob toward the effective footprint; patterns containing '/' match server/tool (repeatable)").Strings()
+	flagExcludeTools = kingpin.Flag("exclude-tool", "Exclude tools matching this glob from the effective footprint; patterns containing '/' match server/tool (repeatable)").Strings()
+	flagToolSetsFile = kingpin.Flag("toolsets", "Path to a VS Code tool sets file (*.toolsets.jsonc)").String()
+	flagToolSets     = kingpin.Flag("toolset", "Only count tools in this VS Code tool set toward the effective footprint (repeatable, requires --toolsets)").Strings()
+
+	// Flags for discovering and analyzing every installed client config.
+	flagDiscover     = kingpin.Flag("discover", "Find and analyze the MCP configs of all installed clients").Bool()
+	flagDiscoverHome = kingpin.Flag("discover.home", "Home directory to search for client configs (defaults to the current user's home)").String()
+
+	// Flags for estimating the cost of carrying server definitions in every
+	// request to the model.
+	flagCostPricing       = kingpin.Flag("cost.pricing", "Path to a JSON pricing file mapping model names to input and cached input prices in USD per million tokens; enables cost estimates").String()
+	flagCostModel         = kingpin.Flag("cost.model", "Model to price from the pricing file (defaults to --tokenizer.model)").String()
+	flagCostRequests      = kingpin.Flag("cost.requests-per-day", "Requests per day that carry the server definitions, for cost estimates").Default("1000").Float64()
+	flagCostCacheHitRatio = kingpin.Flag("cost.cache-hit-ratio", "Fraction of definition tokens read from the prompt cache (0-1), for cost estimates").Default("0").Float64()
+
+	// Flags for the history store of past analysis runs.
+	flagHistoryDir = kingpin.Flag("history.dir", "Directory of the history store: analysis runs are recorded in it, and the history command reports from it").String()
+
+	// Subcommands. Analysis is the default, so the tool can be run without
+	// naming a command.
+	cmdAnalyze        = kingpin.Command("analyze", "Analyze the token footprint of MCP servers (default)").Default()
+	flagWatch         = cmdAnalyze.Flag("watch", "Keep server sessions open and re-render the tables with changes highlighted whenever a server reports changed tools, prompts or resources, or a config file changes").Bool()
+	flagWatchBinaries = cmdAnalyze.Flag("watch.binaries", "With --watch, also restart stdio servers when the executable of their command changes").Bool()
+
+	cmdExporter          = kingpin.Command("exporter", "Periodically analyze the configured servers and serve the results as Prometheus metrics")
+	flagExporterAddress  = cmdExporter.Flag("exporter.listen-address", "Address to serve metrics on").Default(":9877").String()
+	flagExporterPath     = cmdExporter.Flag("exporter.telemetry-path", "Path to serve metrics under").Default("/metrics").String()
+	flagExporterInterval = cmdExporter.Flag("exporter.refresh-interval", "Time between analyses of all servers").Default("5m").Duration()
+
+	cmdTUI = kingpin.Command("tui", "Analyze the configured servers and explore the results in an interactive terminal UI")
+
+	cmdExplain       = kingpin.Command("explain", "Show how the text of a tool, prompt or resource (or of stdin) is split into tokens, and which words cost the most")
+	argExplainTarget = cmdExplain.Arg("component", "Component to explain as server/name, or just its name when a single server is selected; text is read from stdin when omitted or -").String()
+	flagExplainTop   = cmdExplain.Flag("top", "Number of most expensive words to show").Default("10").Int()
+
+	cmdCount        = kingpin.Command("count", "Count the tokens of files or stdin without connecting to a server; JSON tool definitions are broken down per tool and field")
+	argCountFiles   = cmdCount.Arg("files", "Files or globs to count; stdin is read when none are given or for -").Strings()
+	flagCountModels = cmdCount.Flag("model", "Tokenizer model to count with instead of --tokenizer.model; repeat to compare models").Strings()
+
+	cmdStability          = kingpin.Command("stability", "List the components of MCP servers repeatedly, across reconnects, and flag definitions that change between listings and bust the prompt cache")
+	flagStabilitySessions = cmdStability.Flag("sessions", "Sessions to open to each server, one after another").Default("2").Int()
+	flagStabilitySamples  = cmdStability.Flag("samples", "Listings per session").Default("3").Int()
+	flagStabilityInterval = cmdStability.Flag("interval", "Delay between listings, so that content derived from the current time can change").Default("1s").Duration()
+
+	cmdHistory       = kingpin.Command("history", "Show token trends and the biggest growing components recorded in --history.dir")
+	flagHistorySince = cmdHistory.Flag("since", "Time window to report on, ending now").Default("720h").Duration()
+	flagHistoryTop   = cmdHistory.Flag("top", "Number of biggest growing components to show").Default("10").Int()
+)
 
 func main() {
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
+
+	if *flagTokenizerList {
+		renderTokenizers()
+		return
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	if err := run(ctx); err != nil {
+	var err error
+	switch command {
+	case cmdHistory.FullCommand():
+		err = runHistory()
+	case cmdCount.FullCommand():
+		err = runCount()
+	case cmdAnalyze.FullCommand(), cmdExporter.FullCommand(), cmdTUI.FullCommand(), cmdExplain.FullCommand(), cmdStability.FullCommand():
+		err = run(ctx, command)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error running mcp-token-analyzer: %v\n", err)
 		stop()
 		os.Exit(1) //nolint:gocritic
 	}
 }
 
-func run(ctx context.Context) error {
-	counter, err := analyzer.NewTokenCounter(*flagTokenizerModel)
+// run analyzes the configured servers once or continuously with --watch
+// (analyze command), periodically (exporter command), once for interactive
+// exploration (tui command), to explain the tokens of a component (explain
+// command) or to check the stability of their definitions (stability
+// command).
+func run(ctx context.Context, command string) error {
+	resolveInput, err := newInputResolver(*flagInputsFile)
+	if err != nil {
+		return err
+	}
+
+	filter, err := newToolFilter()
+	if err != nil {
+		return err
+	}
+	if *flagRetryJitter < 0 || *flagRetryJitter > 1 {
+		return fmt.Errorf("--retry.jitter must be between 0 and 1, got %v", *flagRetryJitter)
+	}
+
+	// Only one-shot analysis runs are recorded in the history store.
+	if *flagHistoryDir != "" {
+		switch {
+		case command != cmdAnalyze.FullCommand():
+			return fmt.Errorf("--history.dir is not supported by the %s command", command)
+		case *flagDiscover:
+			return errors.New("--history.dir is not supported with --discover")
+		case *flagWatch:
+			return errors.New("--history.dir is not supported with --watch")
+		}
+	}
+
+	a, err := newAnalyzer(filter)
+	if err != nil {
+		return err
+	}
+
+	est, err := newCostEstimator()
+	if err != nil {
+		return err
+	}
+
+	if *flagDiscover {
+		if command != cmdAnalyze.FullCommand() {
+			return fmt.Errorf("--discover is not supported by the %s command", command)
+		}
+		if *flagWatch {
+			return errors.New("--discover is not supported with --watch")
+		}
+		if *flagServer != "" {
+			return errors.New("--discover is not supported with --server")
+		}
+		return runDiscover(ctx, a, est, resolveInput)
+	}
+
+	if command == cmdExplain.FullCommand() && explainsStdin() {
+		return runExplainStdin(a.Counter())
+	}
+
+	if *flagWatch {
+		return runWatch(ctx, a, est, resolveInput)
+	}
+
+	cfg, err := loadOrBuildConfig()
 	if err != nil {
-		return fmt.Errorf("failed to initialize token counter: %w", err)
+		return err
 	}
 
-	cfg, configDir, err := loadOrBuildConfig()
+	servers, err := selectServers(cfg, resolveInput)
 	if err != nil {
 		return err
 	}
 
-	// Unified processing pipeline for both ad-hoc and file-based configs
+	switch command {
+	case cmdExporter.FullCommand():
+		return runExporter(ctx, servers, a, est)
+	case cmdTUI.FullCommand():
+		return runTUI(ctx, servers, a, est)
+	case cmdExplain.FullCommand():
+		return runExplain(ctx, servers, a)
+	case cmdStability.FullCommand():
+		return runStability(ctx, servers, a)
+	}
+	return runAnalysis(ctx, servers, a, est)
+}
+
+// prepareConfig runs the unified processing pipeline shared by ad-hoc,
+// file-based and discovered configs: placeholder interpolation, default
+// inference and validation. Warnings are printed to stderr.
+func prepareConfig(cfg *config.Config, resolveInput config.InputResolver) error {
+	if err := cfg.Interpolate(resolveInput); err != nil {
+		return fmt.Errorf("failed to interpolate config: %w", err)
+	}
 	cfg.InferDefaults()
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
@@ -118,26 +245,82 @@ func run(ctx context.Context) error {
 		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
 	}
 
-	return runAnalysis(ctx, cfg, configDir, counter)
+	return nil
+}
+
+// loadOrBuildConfig returns a Config from either the --config files or CLI
+// flags. Multiple config files are merged with config.MergeConfigs, so later
+// files take precedence. Relative paths in each server resolve against the
+// directory of the file it was defined in (or the CWD for ad-hoc mode).
+func loadOrBuildConfig() (*config.Config, error) {
+	if len(*flagConfigFiles) == 0 {
+		if *flagProject != "" {
+			return loadClaudeCodeConfig("")
+		}
+		return buildConfigFromFlags()
+	}
+
+	var (
+		cfgs          []*config.Config
+		hasClaudeCode bool
+	)
+	for _, path := range *flagConfigFiles {
+		cfg, err := loadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cfgs = append(cfgs, cfg)
+		hasClaudeCode = hasClaudeCode || isClaudeCodeFile(path)
+	}
+
+	if *flagProject != "" && !hasClaudeCode {
+		return nil, errors.New("--config.project requires a Claude Code .claude.json file in --config")
+	}
+
+	return config.MergeConfigs(cfgs...), nil
+}
+
+// loadConfigFile loads a single --config file in the --config.format format.
+func loadConfigFile(path string) (*config.Config, error) {
+	if isClaudeCodeFile(path) {
+		return loadClaudeCodeConfig(path)
+	}
+
+	format := config.Format(*flagConfigFormat)
+	if *flagConfigFormat == configFormatAuto {
+		format = config.FormatAuto
+	}
+	cfg, err := config.LoadConfigFormat(path, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// isClaudeCodeFile reports whether path is Claude Code's user config file
+// and should be loaded with its project-scope merging.
+func isClaudeCodeFile(path string) bool {
+	return *flagConfigFormat == configFormatAuto && filepath.Base(path) == ".claude.json"
 }
 
-// loadOrBuildConfig returns a Config from either a file or CLI flags.
-// It also returns the config directory for resolving relative paths (empty for ad-hoc mode).
-func loadOrBuildConfig() (*config.Config, string, error) {
-	if *flagConfigFile != "" {
-		cfg, err := config.LoadConfig(*flagConfigFile)
+// loadClaudeCodeConfig loads a Claude Code config from userConfig (if set)
+// and the project selected by --config.project, which defaults to the current
+// directory.
+func loadClaudeCodeConfig(userConfig string) (*config.Config, error) {
+	project := *flagProject
+	if project == "" {
+		var err error
+		project, err = os.Getwd()
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to load config: %w", err)
+			return nil, fmt.Errorf("failed to determine project directory: %w", err)
 		}
-		return cfg, filepath.Dir(*flagConfigFile), nil
 	}
 
-	// Build in-memory config from CLI flags
-	cfg, err := buildConfigFromFlags()
+	cfg, err := config.LoadClaudeCodeConfig(userConfig, project)
 	if err != nil {
-		return nil, "", err
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
-	return cfg, "", nil // Empty configDir - relative paths resolve from CWD
+	return cfg, nil
 }
 
 // buildConfigFromFlags creates a Config from CLI flags.
@@ -156,14 +339,23 @@ func buildConfigFromFlags() (*config.Config, error) {
 		if len(parts) > 1 {
 			srv.Args = parts[1:]
 		}
+		srv.Cwd = *flagMCPCwd
 	case "http", "streamable-http":
 		if *flagMCPURL == "" {
 			return nil, errors.New("--mcp.url is required for http transport in single-server mode")
 		}
 		srv.URL = *flagMCPURL
+	case "sse":
+		if *flagMCPURL == "" {
+			return nil, errors.New("--mcp.url is required for sse transport in single-server mode")
+		}
+		// SSE is never inferred from the URL, so it must be set explicitly.
+		srv.Type = config.TransportSSE
+		srv.URL = *flagMCPURL
 	}
 
 	// Return as Config - Name is set from the map key, Type is inferred by InferDefaults()
+	// unless set explicitly above
 	return &config.Config{
 		MCPServers: map[string]*config.ServerConfig{
 			"": srv,
@@ -171,226 +363,123 @@ func buildConfigFromFlags() (*config.Config, error) {
 	}, nil
 }
 
-// runAnalysis performs server analysis on the given config.
-// This is the unified analysis path for both ad-hoc and file-based configs.
-func runAnalysis(ctx context.Context, cfg *config.Config, configDir string, counter *analyzer.TokenCounter) error {
-	servers := cfg.MergedServers()
-
+// selectServers returns the servers of cfg to analyze, all of them or the
+// one selected by --server, after running them through prepareConfig. The
+// selection comes first so that placeholders of other servers in the config
+// need not resolve.
+func selectServers(cfg *config.Config, resolveInput config.InputResolver) (map[string]*config.ServerConfig, error) {
 	// Filter to single server if specified
 	if *flagServer != "" {
-		srv, ok := servers[*flagServer]
-		if !ok {
-			return fmt.Errorf("server %q not found in config", *flagServer)
+		if err := cfg.Select(*flagServer); err != nil {
+			return nil, err
 		}
-		servers = map[string]*config.ServerConfig{*flagServer: srv}
 	}
 
+	if err := prepareConfig(cfg, resolveInput); err != nil {
+		return nil, err
+	}
+
+	servers := cfg.MergedServers()
 	if len(servers) == 0 {
-		return errors.New("no servers to analyze")
+		return nil, errors.New("no servers to analyze")
 	}
 
-	results := connectAndAnalyzeAll(ctx, servers, configDir, counter)
+	return servers, nil
+}
+
+// runAnalysis analyzes the given servers and renders the results, with cost
+// estimates if est is not nil.
+// This is the unified analysis path for both ad-hoc and file-based configs.
+func runAnalysis(ctx context.Context, servers map[string]*config.ServerConfig, a *analyzer.Analyzer, est *cost.Estimator) error {
+	report := a.Analyze(ctx, servers)
 
 	// Single-server results always include detail tables; multi-server
 	// results include them only when explicitly requested via --detail.
-	if len(results) == 1 || *flagDetail {
-		renderDetailTables(results)
+	if len(report.Servers) == 1 || *flagDetail {
+		renderDetailTables(report.Servers, nil)
 	}
 
-	renderSummary(results)
+	renderCollisions("Tool Collisions", report.Collisions())
+	renderSummary("Token Analysis Summary", report.Servers, nil, est)
 
-	var failCount int
-	for _, r := range results {
-		if r.Error != nil {
-			failCount++
-		}
-	}
-	if failCount > 0 {
-		return fmt.Errorf("%d of %d servers failed analysis", failCount, len(results))
-	}
-
-	return nil
+	return errors.Join(report.Err(), recordRun(report))
 }
 
-// analyzeServer connects to a server and analyzes it.
-// Name resolution is handled here: the configured name (map key) takes
-// precedence over the server-reported name from the init response. When
-// running ad-hoc (empty map key), the server-reported name is used as fallback.
-func analyzeServer(ctx context.Context, name string, srv *config.ServerConfig, configDir string, counter *analyzer.TokenCounter) *ServerResult {
-	client, err := mcpclient.NewClientFromConfig(ctx, srv, configDir)
-	if err != nil {
-		return &ServerResult{Name: resolveServerName(name, nil), Error: err}
+// newAnalyzer creates an Analyzer configured by the tokenizer, server process
+// management, retry and tool filter flags.
+func newAnalyzer(filter *toolFilter) (*analyzer.Analyzer, error) {
+	opts := &analyzer.Options{
+		Model:      *flagTokenizerModel,
+		ToolFilter: filter.allows,
+		Client: mcpclient.ClientOptions{
+			SSEFallback:    *flagMCPSSEFallback,
+			StartupTimeout: *flagMCPStartupTimeout,
+			RequestTimeout: *flagMCPRequestTimeout,
+			Retry:          retryPolicy(),
+		},
 	}
-	defer client.Close()
-
-	result := analyzeClient(ctx, client, counter)
-
-	// Resolve the final display name from the configured name and
-	// whatever the server reported during initialization.
-	initResp := client.InitializeResult()
-	var serverInfo *mcp.Implementation
-	if initResp != nil {
-		serverInfo = initResp.ServerInfo
+	if *flagServerLogs != "" {
+		opts.ServerStderr = func(name string, srv *config.ServerConfig) (io.WriteCloser, error) {
+			return createServerLog(*flagServerLogs, name, srv)
+		}
 	}
-	result.Name = resolveServerName(name, serverInfo)
-
-	return result
+	return analyzer.New(opts)
 }
 
-// analyzeClient performs the analysis on an already-connected client.
-func analyzeClient(ctx context.Context, client *mcpclient.Client, counter *analyzer.TokenCounter) *ServerResult {
-	result := &ServerResult{}
-
-	initResp := client.InitializeResult()
-	if initResp == nil {
-		result.Error = errors.New("MCP session not initialized")
-		return result
+// newCostEstimator creates the cost estimator configured by the --cost.*
+// flags, or returns nil if --cost.pricing is not set.
+func newCostEstimator() (*cost.Estimator, error) {
+	if *flagCostPricing == "" {
+		return nil, nil
 	}
 
-	// Instructions
-	result.InstructionTokens = counter.CountTokens(initResp.Instructions)
-
-	// Only analyze components the server advertises support for. This
-	// avoids noisy "Method not found" warnings from servers that don't
-	// implement all capability types.
-	caps := initResp.Capabilities
-	if caps != nil && caps.Tools != nil {
-		result.ToolStats, result.TotalToolTokens = analyzeTools(ctx, client, counter)
-	}
-	if caps != nil && caps.Prompts != nil {
-		result.PromptStats, result.TotalPromptTokens = analyzePrompts(ctx, client, counter)
+	pricing, err := cost.LoadPricing(*flagCostPricing)
+	if err != nil {
+		return nil, err
 	}
-	if caps != nil && caps.Resources != nil {
-		result.ResourceStats, result.TotalResourceTokens = analyzeResources(ctx, client, counter)
+	model := cmp.Or(*flagCostModel, *flagTokenizerModel)
+	e, err := cost.NewEstimator(pricing, model, cost.Usage{
+		RequestsPerDay: *flagCostRequests,
+		CacheHitRatio:  *flagCostCacheHitRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate costs: %w", err)
 	}
-
-	return result
+	return e, nil
 }
 
-// analyzeTools lists and analyzes all tools from the server.
-// Returns the per-tool stats and the accumulated totals.
-// Uses the SDK's paginating iterator to ensure all tools are retrieved.
-// Callers should check server capabilities before calling this function.
-//
-// Note: some variations of mcp.json format have the concept of allowed/denied
-// tools. If/when that is standardized, this project may support it. For now,
-// we always attempt to load and analyze all tools.
-func analyzeTools(ctx context.Context, client *mcpclient.Client, counter *analyzer.TokenCounter) ([]analyzer.ToolTokens, analyzer.ToolTokens) {
-	total := analyzer.ToolTokens{Name: tableLabelTotal}
-
-	var stats []analyzer.ToolTokens
-	for tool, err := range client.Tools(ctx, nil) {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to list tools for %s: %v\n", client.Name, err)
-			break
-		}
-		toolStats, err := counter.AnalyzeTool(tool)
-		if err != nil {
-			logAnalysisError("tool", tool.Name, err)
-			continue
-		}
-		stats = append(stats, toolStats)
-		total.Add(toolStats)
+// retryPolicy returns the retry policy configured by the --retry.* flags.
+func retryPolicy() mcpclient.RetryPolicy {
+	return mcpclient.RetryPolicy{
+		Attempts:       *flagRetryAttempts,
+		InitialBackoff: *flagRetryBackoff,
+		MaxBackoff:     *flagRetryMaxBackoff,
+		Jitter:         *flagRetryJitter,
 	}
-
-	return stats, total
 }
 
-// analyzePrompts lists and analyzes all prompts from the server.
-// Uses the SDK's paginating iterator to ensure all prompts are retrieved.
-// Callers should check server capabilities before calling this function.
-func analyzePrompts(ctx context.Context, client *mcpclient.Client, counter *analyzer.TokenCounter) ([]analyzer.PromptTokens, analyzer.PromptTokens) {
-	total := analyzer.PromptTokens{Name: tableLabelTotal}
-
-	var stats []analyzer.PromptTokens
-	for prompt, err := range client.Prompts(ctx, nil) {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to list prompts for %s: %v\n", client.Name, err)
-			break
-		}
-		promptStats, err := counter.AnalyzePrompt(prompt)
-		if err != nil {
-			logAnalysisError("prompt", prompt.Name, err)
-			continue
-		}
-		stats = append(stats, promptStats)
-		total.Add(promptStats)
+// createServerLog creates the --server-logs file for a stdio server,
+// truncating any log from a previous run. Servers loaded from a config file
+// get a short hash of its path in the file name, so that same-named servers
+// from different files (e.g. in --discover mode) don't share a log.
+func createServerLog(dir, name string, srv *config.ServerConfig) (*os.File, error) {
+	if name == "" {
+		name = filepath.Base(srv.Command)
 	}
-
-	return stats, total
-}
-
-// analyzeResources lists and analyzes all resources and resource templates from the server.
-// Uses the SDK's paginating iterators to ensure all items are retrieved.
-// Callers should check server capabilities before calling this function.
-func analyzeResources(ctx context.Context, client *mcpclient.Client, counter *analyzer.TokenCounter) ([]analyzer.ResourceTokens, analyzer.ResourceTokens) {
-	total := analyzer.ResourceTokens{Name: tableLabelTotal}
-
-	var stats []analyzer.ResourceTokens
-	for resource, err := range client.Resources(ctx, nil) {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to list resources for %s: %v\n", client.Name, err)
-			break
-		}
-		resourceStats, err := counter.AnalyzeResource(resource)
-		if err != nil {
-			logAnalysisError("resource", resource.Name, err)
-			continue
-		}
-		stats = append(stats, resourceStats)
-		total.Add(resourceStats)
+	name = logFileNameReplacer.Replace(name)
+	if srv.Source.File != "" {
+		name += fmt.Sprintf("-%08x", crc32.ChecksumIEEE([]byte(srv.Source.File)))
 	}
 
-	for template, err := range client.ResourceTemplates(ctx, nil) {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to list resource templates for %s: %v\n", client.Name, err)
-			break
-		}
-		templateStats, err := counter.AnalyzeResourceTemplate(template)
-		if err != nil {
-			logAnalysisError("resource template", template.Name, err)
-			continue
-		}
-		stats = append(stats, templateStats)
-		total.Add(templateStats)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create server log directory: %w", err)
 	}
-
-	return stats, total
+	f, err := os.Create(filepath.Join(dir, name+".log"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server log: %w", err)
+	}
+	return f, nil
 }
 
-// connectAndAnalyzeAll connects to all servers in parallel and returns results.
-// The servers map and its ServerConfig values are treated as read-only; concurrent
-// goroutines only read configuration data, never modify it.
-func connectAndAnalyzeAll(ctx context.Context, servers map[string]*config.ServerConfig, configDir string, counter *analyzer.TokenCounter) []*ServerResult {
-	var (
-		results []*ServerResult
-		mu      sync.Mutex
-	)
-
-	// Using errgroup.Group over sync.WaitGroup for built in concurrency
-	// limiter.
-	var g errgroup.Group
-	g.SetLimit(maxConcurrentConnections)
-
-	for name, srv := range servers {
-		g.Go(func() error {
-			result := analyzeServer(ctx, name, srv, configDir, counter)
-			mu.Lock()
-			defer mu.Unlock()
-			results = append(results, result)
-			// Don't return error - we want to continue analyzing other servers
-			return nil
-		})
-	}
-
-	// Errors are captured in individual results, not returned via errgroup.
-	// Each goroutine returns nil to allow all servers to be processed.
-	_ = g.Wait()
-
-	// Sort results by name for consistent output
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Name < results[j].Name
-	})
-
-	return results
-}
+// logFileNameReplacer makes server names safe to use as file names.
+var logFileNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "_", "..", "_")
@@ -1,137 +1,51 @@
 package main
 
 import (
+	"fmt"
+	"hash/crc32"
+	"path/filepath"
 	"testing"
 
-	"github.com/modelcontextprotocol/go-sdk/mcp"
-
-	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
 )
 
-func TestServerResult_TotalTokens(t *testing.T) {
+func TestCreateServerLog(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+
 	tests := []struct {
-		name   string
-		result ServerResult
-		want   int
+		name       string
+		serverName string
+		srv        config.ServerConfig
+		want       string
 	}{
 		{
-			name:   "empty_result",
-			result: ServerResult{},
-			want:   0,
-		},
-		{
-			name: "only_instructions",
-			result: ServerResult{
-				InstructionTokens: 100,
-			},
-			want: 100,
-		},
-		{
-			name: "only_tools",
-			result: ServerResult{
-				TotalToolTokens: analyzer.ToolTokens{TotalTokens: 200},
-			},
-			want: 200,
-		},
-		{
-			name: "only_prompts",
-			result: ServerResult{
-				TotalPromptTokens: analyzer.PromptTokens{TotalTokens: 150},
-			},
-			want: 150,
+			name: "ad_hoc_uses_command",
+			srv:  config.ServerConfig{Command: "/usr/local/bin/mcp-server"},
+			want: "mcp-server.log",
 		},
 		{
-			name: "only_resources",
-			result: ServerResult{
-				TotalResourceTokens: analyzer.ResourceTokens{TotalTokens: 75},
-			},
-			want: 75,
+			name:       "config_server_gets_source_hash",
+			serverName: "github",
+			srv:        config.ServerConfig{Command: "npx", Source: config.Source{File: "/home/user/.cursor/mcp.json"}},
+			want:       fmt.Sprintf("github-%08x.log", crc32.ChecksumIEEE([]byte("/home/user/.cursor/mcp.json"))),
 		},
 		{
-			name: "all_components",
-			result: ServerResult{
-				InstructionTokens:   100,
-				TotalToolTokens:     analyzer.ToolTokens{TotalTokens: 200},
-				TotalPromptTokens:   analyzer.PromptTokens{TotalTokens: 150},
-				TotalResourceTokens: analyzer.ResourceTokens{TotalTokens: 75},
-			},
-			want: 525,
-		},
-		{
-			name: "realistic_values",
-			result: ServerResult{
-				Name:                "test-server",
-				InstructionTokens:   50,
-				TotalToolTokens:     analyzer.ToolTokens{Name: "TOTAL", TotalTokens: 1500},
-				TotalPromptTokens:   analyzer.PromptTokens{Name: "TOTAL", TotalTokens: 300},
-				TotalResourceTokens: analyzer.ResourceTokens{Name: "TOTAL", TotalTokens: 250},
-			},
-			want: 2100,
+			name:       "unsafe_characters_replaced",
+			serverName: "../team/server:dev",
+			srv:        config.ServerConfig{Command: "server"},
+			want:       "__team_server_dev.log",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.result.TotalTokens(); got != tt.want {
-				t.Errorf("ServerResult.TotalTokens() = %d, want %d", got, tt.want)
+			f, err := createServerLog(dir, tt.serverName, &tt.srv)
+			if err != nil {
+				t.Fatalf("createServerLog failed: %v", err)
 			}
-		})
-	}
-}
+			defer f.Close()
 
-func TestResolveServerName(t *testing.T) {
-	tests := []struct {
-		name           string
-		configuredName string
-		serverInfo     *mcp.Implementation
-		want           string
-	}{
-		{
-			name:           "configured_name_only",
-			configuredName: "my-server",
-			serverInfo:     nil,
-			want:           "my-server",
-		},
-		{
-			name:           "configured_name_takes_precedence",
-			configuredName: "configured",
-			serverInfo:     &mcp.Implementation{Name: "server-reported"},
-			want:           "configured",
-		},
-		{
-			name:           "serverinfo_name_when_no_configured",
-			configuredName: "",
-			serverInfo:     &mcp.Implementation{Name: "server-reported"},
-			want:           "server-reported",
-		},
-		{
-			name:           "serverinfo_with_version",
-			configuredName: "",
-			serverInfo:     &mcp.Implementation{Name: "my-mcp-server", Version: "1.0.0"},
-			want:           "my-mcp-server",
-		},
-		{
-			name:           "both_empty_returns_unknown",
-			configuredName: "",
-			serverInfo:     nil,
-			want:           unknownServerName,
-		},
-		{
-			name:           "serverinfo_with_empty_name_falls_through",
-			configuredName: "",
-			serverInfo:     &mcp.Implementation{Name: "", Version: "1.0.0"},
-			want:           unknownServerName,
-		},
-		{
-			name:           "configured_empty_string_uses_serverinfo",
-			configuredName: "",
-			serverInfo:     &mcp.Implementation{Name: "fallback-name"},
-			want:           "fallback-name",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := resolveServerName(tt.configuredName, tt.serverInfo); got != tt.want {
-				t.Errorf("resolveServerName(%q, %v) = %q, want %q", tt.configuredName, tt.serverInfo, got, tt.want)
+			if got := f.Name(); got != filepath.Join(dir, tt.want) {
+				t.Errorf("expected log file %q, got %q", filepath.Join(dir, tt.want), got)
 			}
 		})
 	}
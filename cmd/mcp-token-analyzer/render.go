@@ -5,19 +5,86 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/aquasecurity/table"
+	"golang.org/x/term"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 
 	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
 )
 
 // printer is used for locale-aware number formatting with thousands separators.
 var printer = message.NewPrinter(language.English)
 
+// ANSI escape sequences for highlighting changed token counts.
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// highlightChanges enables colored Change columns when stdout is a terminal.
+var highlightChanges = term.IsTerminal(int(os.Stdout.Fd()))
+
+// formatChange formats how a token count changed since a previous report,
+// for the Change column: "new" if there was no previous count, nothing if
+// the count is unchanged, and the signed difference otherwise. On a
+// terminal, growth is highlighted in red and shrinkage in green.
+func formatChange(cur, prev int, existed bool) string {
+	color, text := "", ""
+	switch {
+	case !existed:
+		color, text = ansiYellow, "new"
+	case cur > prev:
+		color, text = ansiRed, printer.Sprintf("%+d", cur-prev)
+	case cur < prev:
+		color, text = ansiGreen, printer.Sprintf("%+d", cur-prev)
+	default:
+		return ""
+	}
+	if !highlightChanges {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// resultKey identifies a server result across reports.
+func resultKey(r *analyzer.ServerResult) string {
+	return r.SourceFile + "\x00" + r.Name
+}
+
+// formatCost formats a cost in USD, showing costs below a cent as such
+// rather than as $0.00.
+func formatCost(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return "<$0.01"
+	}
+	return printer.Sprintf("$%.2f", usd)
+}
+
+// renderCostAssumptions prints the pricing and usage the cost columns are
+// estimated from, if cost estimation is enabled (e is not nil).
+func renderCostAssumptions(e *cost.Estimator) {
+	if e == nil {
+		return
+	}
+	fmt.Printf("\nEstimated cost: %s at %s per million input tokens (%s cached), %s requests/day, %.0f%% cache hits, %d-day months\n",
+		e.Model,
+		printer.Sprintf("$%.2f", e.Price.Input),
+		printer.Sprintf("$%.2f", e.Price.CachedInput),
+		printer.Sprintf("%v", e.Usage.RequestsPerDay),
+		e.Usage.CacheHitRatio*100,
+		cost.DaysPerMonth,
+	)
+}
+
 // renderContextUsage prints context window usage as a percentage if a limit is configured.
 func renderContextUsage(grandTotal int) {
 	if *flagContextLimit > 0 {
@@ -30,55 +97,193 @@ func renderContextUsage(grandTotal int) {
 	}
 }
 
-// renderSummary renders the summary table for all server results.
-func renderSummary(results []*ServerResult) {
-	fmt.Println("\nToken Analysis Summary")
+// renderSummary renders the summary table for all server results under the given title.
+// When the results come from more than one config file, each row is labeled
+// with its "path:server" source. When any server is disabled or has excluded
+// tools, an Effective column shows the footprint the client actually sends to
+// the model, and context usage is computed from it. If est is not nil, Daily
+// Cost and Monthly Cost columns show the cost of carrying the effective
+// footprint in every request. When any connection or list request
+// was retried, a Retries column shows how often. When prev is not
+// nil, a Change column shows how each server's total changed since then, and
+// servers that are no longer present are listed as removed. Components that
+// could not be analyzed are reported on stderr.
+func renderSummary(title string, results, prev []*analyzer.ServerResult, est *cost.Estimator) {
+	for _, r := range results {
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: server %q: %s\n", r.Name, warning)
+		}
+	}
+
+	fmt.Println("\n" + title)
 	summaryTable := table.New(os.Stdout)
-	summaryTable.SetHeaders("MCP Server", "Instructions", "Tools", "Prompts", "Resources", "Total Tokens")
 
-	var totalInstructionTokens, totalTools, totalPrompts, totalResources, grandTotal int
+	showSource := hasMultipleSources(results)
+	showEffective := hasFilteredResults(results)
+	showRetries := hasRetries(results)
+	showChange := prev != nil
+	showCost := est != nil
+
+	headers := []string{"MCP Server", "Instructions", "Tools", "Prompts", "Resources", "Total Tokens"}
+	if showEffective {
+		headers = append(headers, "Effective")
+	}
+	if showCost {
+		headers = append(headers, "Daily Cost", "Monthly Cost")
+	}
+	if showRetries {
+		headers = append(headers, "Retries")
+	}
+	if showChange {
+		headers = append(headers, "Change")
+	}
+	summaryTable.SetHeaders(headers...)
+
+	prevTotals := make(map[string]int)
+	var prevGrandTotal int
+	for _, r := range prev {
+		if r.Error == nil {
+			prevTotals[resultKey(r)] = r.TotalTokens()
+			prevGrandTotal += r.TotalTokens()
+		}
+	}
+
+	label := func(r *analyzer.ServerResult) string {
+		name := r.Name
+		if showSource && r.SourceFile != "" {
+			name = r.SourceFile + ":" + name
+		}
+		if r.Disabled {
+			name += " (disabled)"
+		}
+		return name
+	}
+
+	var totalInstructionTokens, totalTools, totalPrompts, totalResources, grandTotal, effectiveTotal, totalRetries int
 
 	for _, r := range results {
 		if r.Error != nil {
-			summaryTable.AddRow(
-				r.Name,
-				"ERROR",
-				"",
-				"",
-				"",
-				r.Error.Error(),
-			)
+			row := []string{label(r), "ERROR", "", "", "", r.Error.Error()}
+			if showEffective {
+				row = append(row, "")
+			}
+			if showCost {
+				row = append(row, "", "")
+			}
+			if showRetries {
+				row = append(row, printer.Sprintf("%d", r.Retries))
+			}
+			if showChange {
+				row = append(row, "")
+			}
+			summaryTable.AddRow(row...)
+			totalRetries += r.Retries
 			continue
 		}
 
 		total := r.TotalTokens()
-		summaryTable.AddRow(
-			r.Name,
+		row := []string{
+			label(r),
 			printer.Sprintf("%d", r.InstructionTokens),
 			printer.Sprintf("%d", r.TotalToolTokens.TotalTokens),
 			printer.Sprintf("%d", r.TotalPromptTokens.TotalTokens),
 			printer.Sprintf("%d", r.TotalResourceTokens.TotalTokens),
 			printer.Sprintf("%d", total),
-		)
+		}
+		if showEffective {
+			row = append(row, printer.Sprintf("%d", r.EffectiveTokens()))
+		}
+		if showCost {
+			row = append(row, formatCost(est.Daily(r.EffectiveTokens())), formatCost(est.Monthly(r.EffectiveTokens())))
+		}
+		if showRetries {
+			row = append(row, printer.Sprintf("%d", r.Retries))
+		}
+		if showChange {
+			prevTotal, existed := prevTotals[resultKey(r)]
+			row = append(row, formatChange(total, prevTotal, existed))
+		}
+		summaryTable.AddRow(row...)
 
 		totalInstructionTokens += r.InstructionTokens
 		totalTools += r.TotalToolTokens.TotalTokens
 		totalPrompts += r.TotalPromptTokens.TotalTokens
 		totalResources += r.TotalResourceTokens.TotalTokens
 		grandTotal += total
+		effectiveTotal += r.EffectiveTokens()
+		totalRetries += r.Retries
+	}
+
+	if showChange {
+		for _, r := range prev {
+			if r.Error != nil || slices.ContainsFunc(results, func(cur *analyzer.ServerResult) bool { return resultKey(cur) == resultKey(r) }) {
+				continue
+			}
+			row := make([]string, len(headers))
+			row[0] = label(r) + " (removed)"
+			row[len(row)-1] = formatChange(0, r.TotalTokens(), true)
+			summaryTable.AddRow(row...)
+		}
 	}
 
-	summaryTable.AddFooters(
+	footers := []string{
 		tableLabelTotal,
 		printer.Sprintf("%d", totalInstructionTokens),
 		printer.Sprintf("%d", totalTools),
 		printer.Sprintf("%d", totalPrompts),
 		printer.Sprintf("%d", totalResources),
 		printer.Sprintf("%d", grandTotal),
-	)
+	}
+	if showEffective {
+		footers = append(footers, printer.Sprintf("%d", effectiveTotal))
+	}
+	if showCost {
+		footers = append(footers, formatCost(est.Daily(effectiveTotal)), formatCost(est.Monthly(effectiveTotal)))
+	}
+	if showRetries {
+		footers = append(footers, printer.Sprintf("%d", totalRetries))
+	}
+	if showChange {
+		footers = append(footers, formatChange(grandTotal, prevGrandTotal, true))
+	}
+	summaryTable.AddFooters(footers...)
 	summaryTable.Render()
 
-	renderContextUsage(grandTotal)
+	renderContextUsage(effectiveTotal)
+	renderCostAssumptions(est)
+}
+
+// hasFilteredResults reports whether any result's effective footprint
+// differs from its full footprint.
+func hasFilteredResults(results []*analyzer.ServerResult) bool {
+	for _, r := range results {
+		if r.Error == nil && r.IsFiltered() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRetries reports whether any server needed retries to connect or list
+// its components.
+func hasRetries(results []*analyzer.ServerResult) bool {
+	for _, r := range results {
+		if r.Retries > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMultipleSources reports whether results were loaded from more than one
+// config file.
+func hasMultipleSources(results []*analyzer.ServerResult) bool {
+	for _, r := range results {
+		if r.SourceFile != results[0].SourceFile {
+			return true
+		}
+	}
+	return false
 }
 
 // detailItem holds a stats value associated with a server for detail tables.
@@ -88,30 +293,54 @@ type detailItem[T any] struct {
 }
 
 // renderDetailTable collects items from all server results, sorts by total tokens,
-// and renders a per-component detail table.
+// and renders a per-component detail table. When prev is not nil, a Change
+// column shows how each component's total changed since then, and components
+// that are no longer present are listed as removed.
 func renderDetailTable[T any](
-	results []*ServerResult,
+	results []*analyzer.ServerResult,
+	prev []*analyzer.ServerResult,
 	title string,
 	headers []string,
-	extract func(r *ServerResult) []T,
+	extract func(r *analyzer.ServerResult) []T,
 	itemName func(T) string,
 	totalTokens func(T) int,
 	rowValues func(T) []string,
 ) {
-	var items []detailItem[T]
-	for _, r := range results {
-		if r.Error != nil {
-			continue
+	collect := func(results []*analyzer.ServerResult) []detailItem[T] {
+		var items []detailItem[T]
+		for _, r := range results {
+			if r.Error != nil {
+				continue
+			}
+			for _, item := range extract(r) {
+				items = append(items, detailItem[T]{
+					Server: r.Name,
+					Stats:  item,
+				})
+			}
 		}
-		for _, item := range extract(r) {
-			items = append(items, detailItem[T]{
-				Server: r.Name,
-				Stats:  item,
-			})
+		return items
+	}
+	key := func(item detailItem[T]) [2]string {
+		return [2]string{item.Server, itemName(item.Stats)}
+	}
+
+	items := collect(results)
+	current := make(map[[2]string]bool, len(items))
+	for _, item := range items {
+		current[key(item)] = true
+	}
+
+	prevTotals := make(map[[2]string]int)
+	var removed []detailItem[T]
+	for _, item := range collect(prev) {
+		prevTotals[key(item)] += totalTokens(item.Stats)
+		if !current[key(item)] {
+			removed = append(removed, item)
 		}
 	}
 
-	if len(items) == 0 {
+	if len(items) == 0 && len(removed) == 0 {
 		return
 	}
 
@@ -119,23 +348,56 @@ func renderDetailTable[T any](
 		return totalTokens(items[i].Stats) > totalTokens(items[j].Stats)
 	})
 
+	showChange := prev != nil
+	if showChange {
+		headers = append(slices.Clone(headers), "Change")
+	}
+
 	fmt.Println("\n" + title)
 	t := table.New(os.Stdout)
 	t.SetHeaders(headers...)
 	for _, item := range items {
 		row := append([]string{item.Server, itemName(item.Stats)}, rowValues(item.Stats)...)
+		if showChange {
+			prevTotal, existed := prevTotals[key(item)]
+			row = append(row, formatChange(totalTokens(item.Stats), prevTotal, existed))
+		}
+		t.AddRow(row...)
+	}
+	for _, item := range removed {
+		row := make([]string, len(headers))
+		row[0], row[1] = item.Server, itemName(item.Stats)+" (removed)"
+		row[len(row)-1] = formatChange(0, totalTokens(item.Stats), true)
 		t.AddRow(row...)
 	}
 	t.Render()
 }
 
-// renderDetailTables renders per-component detail tables across all servers.
-func renderDetailTables(results []*ServerResult) {
+// markExcludedTools returns the server's tool stats with tools that are not
+// part of the effective footprint marked in their name.
+func markExcludedTools(r *analyzer.ServerResult) []analyzer.ToolTokens {
+	if !r.IsFiltered() {
+		return r.ToolStats
+	}
+
+	stats := slices.Clone(r.ToolStats)
+	for i := range stats {
+		if r.Disabled || r.ExcludedTools[stats[i].Name] {
+			stats[i].Name += " (excluded)"
+		}
+	}
+	return stats
+}
+
+// renderDetailTables renders per-component detail tables across all servers,
+// with changes since prev if it is not nil.
+func renderDetailTables(results, prev []*analyzer.ServerResult) {
 	renderDetailTable(
 		results,
+		prev,
 		"Tool Analysis (sorted by total tokens)",
 		[]string{"Server", "Tool", "Name", "Desc", "Schema", "Output", "Annot.", "Total"},
-		func(r *ServerResult) []analyzer.ToolTokens { return r.ToolStats },
+		markExcludedTools,
 		func(t analyzer.ToolTokens) string { return t.Name },
 		func(t analyzer.ToolTokens) int { return t.TotalTokens },
 		func(t analyzer.ToolTokens) []string {
@@ -152,9 +414,10 @@ func renderDetailTables(results []*ServerResult) {
 
 	renderDetailTable(
 		results,
+		prev,
 		"Prompt Analysis (sorted by total tokens)",
 		[]string{"Server", "Prompt", "Name", "Desc", "Args", "Total"},
-		func(r *ServerResult) []analyzer.PromptTokens { return r.PromptStats },
+		func(r *analyzer.ServerResult) []analyzer.PromptTokens { return r.PromptStats },
 		func(p analyzer.PromptTokens) string { return p.Name },
 		func(p analyzer.PromptTokens) int { return p.TotalTokens },
 		func(p analyzer.PromptTokens) []string {
@@ -169,9 +432,10 @@ func renderDetailTables(results []*ServerResult) {
 
 	renderDetailTable(
 		results,
+		prev,
 		"Resource Analysis (sorted by total tokens)",
 		[]string{"Server", "Resource", "Name", "URI", "Desc", "Total"},
-		func(r *ServerResult) []analyzer.ResourceTokens { return r.ResourceStats },
+		func(r *analyzer.ServerResult) []analyzer.ResourceTokens { return r.ResourceStats },
 		func(res analyzer.ResourceTokens) string { return res.Name },
 		func(res analyzer.ResourceTokens) int { return res.TotalTokens },
 		func(res analyzer.ResourceTokens) []string {
@@ -184,3 +448,125 @@ func renderDetailTables(results []*ServerResult) {
 		},
 	)
 }
+
+// collisionKeyWidth is the width descriptions are truncated to in the
+// Shared column of the collisions table.
+const collisionKeyWidth = 60
+
+// renderCollisions renders the tools that collide across servers under
+// title, if there are any.
+func renderCollisions(title string, collisions []analyzer.Collision) {
+	if len(collisions) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s (sorted by redundant tokens)\n", title)
+	t := table.New(os.Stdout)
+	t.SetHeaders("Kind", "Shared", "Tools", "Redundant Tokens")
+
+	for _, c := range collisions {
+		tools := make([]string, len(c.Tools))
+		for i, ref := range c.Tools {
+			tools[i] = ref.Server + "/" + ref.Tool
+		}
+		key := c.Key
+		if c.Kind == analyzer.CollisionDescription {
+			key = truncate(strings.Join(strings.Fields(key), " "), collisionKeyWidth)
+		}
+		t.AddRow(c.Kind, key, strings.Join(tools, ", "), printer.Sprintf("%d", c.RedundantTokens))
+	}
+	// Groups overlap (exact duplicates often share descriptions too), so
+	// there is no total.
+	t.Render()
+}
+
+// renderTokenizers renders the model and encoding names --tokenizer.model
+// accepts and where their encodings are cached.
+func renderTokenizers() {
+	t := table.New(os.Stdout)
+	t.SetHeaders("Model", "Encoding", "Vocab Size", "Offline", "Backend")
+	for _, info := range analyzer.Tokenizers() {
+		offline := "no"
+		if info.Offline {
+			offline = "yes"
+		}
+		t.AddRow(info.Model, info.Encoding, printer.Sprintf("%d", info.VocabSize), offline, info.Backend)
+	}
+	t.Render()
+
+	fmt.Printf("\nModels ending in * match any model name with that prefix. Encodings are\ndownloaded on first use and cached in %s.\n", analyzer.TokenizerCacheDir())
+}
+
+// renderClientSummary renders per-client totals for --discover mode. If est is
+// not nil, Daily Cost and Monthly Cost columns show the cost of each client's
+// effective footprint, as in renderSummary.
+func renderClientSummary(clients []*clientResult, est *cost.Estimator) {
+	fmt.Println("\nClient Summary")
+	t := table.New(os.Stdout)
+	showEffective := slices.ContainsFunc(clients, func(c *clientResult) bool {
+		return hasFilteredResults(c.Report.Servers)
+	})
+
+	headers := []string{"Client", "Config", "Servers", "Failed", "Total Tokens"}
+	if showEffective {
+		headers = append(headers, "Effective")
+	}
+	if est != nil {
+		headers = append(headers, "Daily Cost", "Monthly Cost")
+	}
+	t.SetHeaders(headers...)
+
+	var totalServers, totalFailed, grandTotal, effectiveTotal int
+	for _, c := range clients {
+		cfgPath := c.Paths[0]
+		if len(c.Paths) > 1 {
+			cfgPath = fmt.Sprintf("%d files", len(c.Paths))
+		}
+
+		total, effective := printer.Sprintf("%d", c.Report.TotalTokens()), printer.Sprintf("%d", c.Report.EffectiveTokens())
+		if len(c.Errors) > 0 && len(c.Report.Servers) == 0 {
+			total, effective = "CONFIG ERROR", ""
+		}
+
+		failed := c.Report.Failures()
+		row := []string{
+			c.Client,
+			cfgPath,
+			strconv.Itoa(len(c.Report.Servers)),
+			strconv.Itoa(failed),
+			total,
+		}
+		if showEffective {
+			row = append(row, effective)
+		}
+		if est != nil {
+			if effective == "" {
+				row = append(row, "", "")
+			} else {
+				row = append(row, formatCost(est.Daily(c.Report.EffectiveTokens())), formatCost(est.Monthly(c.Report.EffectiveTokens())))
+			}
+		}
+		t.AddRow(row...)
+
+		totalServers += len(c.Report.Servers)
+		totalFailed += failed
+		grandTotal += c.Report.TotalTokens()
+		effectiveTotal += c.Report.EffectiveTokens()
+	}
+
+	footers := []string{
+		tableLabelTotal,
+		"",
+		strconv.Itoa(totalServers),
+		strconv.Itoa(totalFailed),
+		printer.Sprintf("%d", grandTotal),
+	}
+	if showEffective {
+		footers = append(footers, printer.Sprintf("%d", effectiveTotal))
+	}
+	if est != nil {
+		footers = append(footers, formatCost(est.Daily(effectiveTotal)), formatCost(est.Monthly(effectiveTotal)))
+	}
+	t.AddFooters(footers...)
+	t.Render()
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestFormatChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		cur     int
+		prev    int
+		existed bool
+		want    string
+	}{
+		{name: "new", cur: 120, want: "new"},
+		{name: "unchanged", cur: 120, prev: 120, existed: true, want: ""},
+		{name: "grown", cur: 1500, prev: 120, existed: true, want: "+1,380"},
+		{name: "shrunk", cur: 100, prev: 120, existed: true, want: "-20"},
+		{name: "removed", cur: 0, prev: 120, existed: true, want: "-120"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatChange(tt.cur, tt.prev, tt.existed); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		want string
+	}{
+		{usd: 0, want: "$0.00"},
+		{usd: 0.004, want: "<$0.01"},
+		{usd: 0.01, want: "$0.01"},
+		{usd: 12.345, want: "$12.35"},
+		{usd: 1234.5, want: "$1,234.50"},
+	}
+	for _, tt := range tests {
+		if got := formatCost(tt.usd); got != tt.want {
+			t.Errorf("formatCost(%v): expected %q, got %q", tt.usd, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// stability.go contains the stability command, which lists the components of
+// servers repeatedly to find definitions that change between requests and
+// bust the model provider's prompt cache.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/table"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// runStability checks the stability of the given servers' definitions and
+// renders the results. Servers that fail or turn out unstable are reported
+// in the returned error.
+func runStability(ctx context.Context, servers map[string]*config.ServerConfig, a *analyzer.Analyzer) error {
+	sessions, samples := *flagStabilitySessions, *flagStabilitySamples
+	if sessions < 1 || samples < 1 {
+		return errors.New("--sessions and --samples must be at least 1")
+	}
+	if sessions*samples < 2 {
+		return errors.New("at least two listings are needed for a comparison: raise --sessions or --samples")
+	}
+
+	results := a.CheckStability(ctx, servers, &analyzer.StabilityOptions{
+		Sessions: sessions,
+		Samples:  samples,
+		Interval: *flagStabilityInterval,
+	})
+
+	renderUnstableComponents(results)
+	renderStabilitySummary(results)
+	return stabilityErr(results)
+}
+
+// stabilityErr returns an error counting the failed and unstable results.
+func stabilityErr(results []*analyzer.StabilityResult) error {
+	var failed, unstable int
+	for _, r := range results {
+		switch {
+		case r.Error != nil:
+			failed++
+		case !r.Stable():
+			unstable++
+		}
+	}
+
+	var errs []error
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d servers failed the stability check", failed, len(results)))
+	}
+	if unstable > 0 {
+		errs = append(errs, fmt.Errorf("%d of %d servers list unstable definitions", unstable, len(results)))
+	}
+	return errors.Join(errs...)
+}
+
+// describeInstability describes how a component varied between listings,
+// for the Change column.
+func describeInstability(c analyzer.ComponentStability) string {
+	var changes []string
+	if c.ContentChanged {
+		changes = append(changes, "content changed")
+	}
+	if c.KeyOrderChanged {
+		changes = append(changes, "key order changed")
+	}
+	if c.Missing {
+		changes = append(changes, "missing from some listings")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// renderUnstableComponents renders the components whose definitions varied
+// between listings, if there are any.
+func renderUnstableComponents(results []*analyzer.StabilityResult) {
+	t := table.New(os.Stdout)
+	t.SetHeaders("MCP Server", "Kind", "Name", "Tokens", "Change")
+
+	var rows int
+	for _, r := range results {
+		for _, c := range r.Components {
+			if c.Stable() {
+				continue
+			}
+			t.AddRow(r.Name, c.Kind, c.Name, printer.Sprintf("%d", c.Tokens), describeInstability(c))
+			rows++
+		}
+	}
+	if rows == 0 {
+		return
+	}
+
+	fmt.Println("\nUnstable Components")
+	t.Render()
+}
+
+// renderStabilitySummary renders whether each server listed its definitions
+// identically every time, and how many tokens a change sends uncached.
+func renderStabilitySummary(results []*analyzer.StabilityResult) {
+	showReordered := false
+	for _, r := range results {
+		showReordered = showReordered || len(r.ReorderedKinds) > 0
+	}
+
+	headers := []string{"MCP Server", "Stability", "Listings", "Components", "Total Tokens", "Cache-Busting Tokens"}
+	if showReordered {
+		headers = append(headers, "Reordered")
+	}
+
+	fmt.Println("\nPrompt Cache Stability")
+	t := table.New(os.Stdout)
+	t.SetHeaders(headers...)
+
+	var total, busting int
+	for _, r := range results {
+		if r.Error != nil {
+			row := []string{r.Name, "ERROR", r.Error.Error(), "", "", ""}
+			if showReordered {
+				row = append(row, "")
+			}
+			t.AddRow(row...)
+			continue
+		}
+
+		stability := "stable"
+		if !r.Stable() {
+			stability = "unstable"
+		}
+		row := []string{
+			r.Name,
+			stability,
+			printer.Sprintf("%d", r.Listings),
+			printer.Sprintf("%d", len(r.Components)),
+			printer.Sprintf("%d", r.TotalTokens),
+			printer.Sprintf("%d", r.CacheBustingTokens),
+		}
+		if showReordered {
+			row = append(row, strings.Join(r.ReorderedKinds, ", "))
+		}
+		t.AddRow(row...)
+
+		total += r.TotalTokens
+		busting += r.CacheBustingTokens
+	}
+
+	footers := []string{tableLabelTotal, "", "", "", printer.Sprintf("%d", total), printer.Sprintf("%d", busting)}
+	if showReordered {
+		footers = append(footers, "")
+	}
+	t.AddFooters(footers...)
+	t.Render()
+
+	if busting > 0 {
+		fmt.Println("\nCache-busting tokens are sent uncached whenever the definitions change: the first changed definition invalidates the prompt cache from there on.")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+func TestDescribeInstability(t *testing.T) {
+	tests := []struct {
+		name      string
+		component analyzer.ComponentStability
+		want      string
+	}{
+		{name: "stable", component: analyzer.ComponentStability{}, want: ""},
+		{name: "content", component: analyzer.ComponentStability{ContentChanged: true}, want: "content changed"},
+		{name: "key order", component: analyzer.ComponentStability{KeyOrderChanged: true}, want: "key order changed"},
+		{
+			name:      "content and missing",
+			component: analyzer.ComponentStability{ContentChanged: true, Missing: true},
+			want:      "content changed, missing from some listings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeInstability(tt.component); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStabilityErr(t *testing.T) {
+	stable := &analyzer.StabilityResult{Name: "stable", Listings: 2}
+	unstable := &analyzer.StabilityResult{Name: "unstable", Listings: 2, ReorderedKinds: []string{analyzer.KindTool}}
+	failed := &analyzer.StabilityResult{Name: "failed", Error: errors.New("connection refused")}
+
+	if err := stabilityErr([]*analyzer.StabilityResult{stable}); err != nil {
+		t.Errorf("expected no error for stable servers, got %v", err)
+	}
+
+	err := stabilityErr([]*analyzer.StabilityResult{stable, unstable, failed})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"1 of 3 servers failed", "1 of 3 servers list unstable definitions"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %q", want, err)
+		}
+	}
+}
@@ -0,0 +1,637 @@
+// tui.go contains the interactive terminal UI for exploring analysis results
+// as a server → component → field tree.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+// Levels of the TUI tree.
+const (
+	tuiServer = iota
+	tuiComponent
+	tuiField
+)
+
+// tuiNode is a server, component or field in the TUI tree.
+type tuiNode struct {
+	level    int
+	label    string
+	kind     string // analyzer.Kind* of components
+	tokens   int
+	raw      string // Raw text of fields
+	enabled  bool   // Whether servers and components count toward the running total
+	expanded bool
+	parent   *tuiNode
+	children []*tuiNode
+
+	result *analyzer.ServerResult // Set for servers
+}
+
+// field returns the named field of a component, or nil if it has none.
+func (n *tuiNode) field(label string) *tuiNode {
+	for _, c := range n.children {
+		if c.label == label {
+			return c
+		}
+	}
+	return nil
+}
+
+// fieldTokens returns the token count of the named field of a component.
+func (n *tuiNode) fieldTokens(label string) int {
+	if f := n.field(label); f != nil {
+		return f.tokens
+	}
+	return 0
+}
+
+// Sort orders of the components of each server (and of the servers).
+var tuiSortOrders = []string{"total", "description", "name"}
+
+// Field labels of the TUI tree.
+const (
+	fieldName         = "name"
+	fieldDescription  = "description"
+	fieldInputSchema  = "input schema"
+	fieldOutputSchema = "output schema"
+	fieldAnnotations  = "annotations"
+	fieldArguments    = "arguments"
+	fieldURI          = "uri"
+	fieldText         = "text"
+)
+
+// runTUI analyzes the given servers and explores the results in an
+// interactive terminal UI, with cost estimates if est is not nil.
+func runTUI(ctx context.Context, servers map[string]*config.ServerConfig, a *analyzer.Analyzer, est *cost.Estimator) error {
+	fmt.Fprintf(os.Stderr, "Analyzing %d servers...\n", len(servers))
+	report := a.Analyze(ctx, servers)
+
+	p := tea.NewProgram(newTUIModel(report, *flagContextLimit, est), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to run terminal UI: %w", err)
+	}
+	return report.Err()
+}
+
+// newTUITree builds the tree of servers, their components and the
+// components' fields. Servers and tools start enabled according to the
+// effective footprint of the report.
+func newTUITree(report *analyzer.Report) []*tuiNode {
+	var servers []*tuiNode
+	for _, r := range report.Servers {
+		server := &tuiNode{
+			level:   tuiServer,
+			label:   r.Name,
+			tokens:  r.TotalTokens(),
+			enabled: !r.Disabled && r.Error == nil,
+			result:  r,
+		}
+		add := func(kind, label string, tokens int, enabled bool, fields ...*tuiNode) {
+			c := &tuiNode{level: tuiComponent, label: label, kind: kind, tokens: tokens, enabled: enabled, parent: server}
+			for _, f := range fields {
+				f.level, f.parent = tuiField, c
+				c.children = append(c.children, f)
+			}
+			server.children = append(server.children, c)
+		}
+
+		if r.Instructions != "" {
+			add(analyzer.KindInstructions, "instructions", r.InstructionTokens, true,
+				&tuiNode{label: fieldText, tokens: r.InstructionTokens, raw: r.Instructions})
+		}
+		for i, t := range r.ToolStats {
+			tool := &mcp.Tool{Name: t.Name}
+			if i < len(r.Tools) {
+				tool = r.Tools[i]
+			}
+			fields := []*tuiNode{
+				{label: fieldName, tokens: t.NameTokens, raw: tool.Name},
+				{label: fieldDescription, tokens: t.DescTokens, raw: tool.Description},
+				{label: fieldInputSchema, tokens: t.SchemaTokens, raw: indentJSON(tool.InputSchema)},
+			}
+			if tool.OutputSchema != nil {
+				fields = append(fields, &tuiNode{label: fieldOutputSchema, tokens: t.OutputSchemaTokens, raw: indentJSON(tool.OutputSchema)})
+			}
+			if tool.Annotations != nil {
+				fields = append(fields, &tuiNode{label: fieldAnnotations, tokens: t.AnnotationsTokens, raw: indentJSON(tool.Annotations)})
+			}
+			add(analyzer.KindTool, t.Name, t.TotalTokens, !r.ExcludedTools[t.Name], fields...)
+		}
+		for i, p := range r.PromptStats {
+			prompt := &mcp.Prompt{Name: p.Name}
+			if i < len(r.Prompts) {
+				prompt = r.Prompts[i]
+			}
+			add(analyzer.KindPrompt, p.Name, p.TotalTokens, true,
+				&tuiNode{label: fieldName, tokens: p.NameTokens, raw: prompt.Name},
+				&tuiNode{label: fieldDescription, tokens: p.DescTokens, raw: prompt.Description},
+				&tuiNode{label: fieldArguments, tokens: p.ArgsTokens, raw: indentJSON(prompt.Arguments)},
+			)
+		}
+		for i, res := range r.ResourceStats {
+			var uri, desc string
+			switch {
+			case i < len(r.Resources):
+				uri, desc = r.Resources[i].URI, r.Resources[i].Description
+			case i-len(r.Resources) < len(r.ResourceTemplates):
+				tmpl := r.ResourceTemplates[i-len(r.Resources)]
+				uri, desc = tmpl.URITemplate, tmpl.Description
+			}
+			add(analyzer.KindResource, res.Name, res.TotalTokens, true,
+				&tuiNode{label: fieldName, tokens: res.NameTokens, raw: res.Name},
+				&tuiNode{label: fieldURI, tokens: res.URITokens, raw: uri},
+				&tuiNode{label: fieldDescription, tokens: res.DescTokens, raw: desc},
+			)
+		}
+
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+// indentJSON renders v as indented JSON for display.
+func indentJSON(v any) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(b)
+}
+
+// tuiFilter selects components by name or, with a leading '>' or '<', by
+// their total token count.
+type tuiFilter struct {
+	text      string
+	threshold int
+	op        byte
+}
+
+// parseTUIFilter parses a search box query.
+func parseTUIFilter(query string) tuiFilter {
+	query = strings.TrimSpace(query)
+	if len(query) > 1 && (query[0] == '>' || query[0] == '<') {
+		if n, err := strconv.Atoi(strings.TrimSpace(query[1:])); err == nil {
+			return tuiFilter{op: query[0], threshold: n}
+		}
+	}
+	return tuiFilter{text: strings.ToLower(query)}
+}
+
+// active reports whether the filter selects anything less than everything.
+func (f tuiFilter) active() bool {
+	return f.op != 0 || f.text != ""
+}
+
+// matches reports whether a component passes the filter.
+func (f tuiFilter) matches(n *tuiNode) bool {
+	switch f.op {
+	case '>':
+		return n.tokens > f.threshold
+	case '<':
+		return n.tokens < f.threshold
+	}
+	return strings.Contains(strings.ToLower(n.label), f.text)
+}
+
+// tuiModel is the bubbletea model of the terminal UI.
+type tuiModel struct {
+	servers   []*tuiNode
+	limit     int
+	estimator *cost.Estimator // nil unless cost estimation is enabled
+
+	rows   []*tuiNode // Visible nodes, in display order
+	cursor int
+	offset int // First visible row
+
+	sortOrder int
+	filter    tuiFilter
+	search    textinput.Model
+	preview   viewport.Model
+
+	width, height int
+}
+
+// newTUIModel creates the terminal UI model for a report, with the running
+// total measured against limit (if positive) and priced by estimator (if not
+// nil).
+func newTUIModel(report *analyzer.Report, limit int, estimator *cost.Estimator) *tuiModel {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "name, >tokens or <tokens"
+
+	m := &tuiModel{
+		servers:   newTUITree(report),
+		limit:     limit,
+		estimator: estimator,
+		search:    search,
+		preview:   viewport.New(80, 10),
+		width:     80,
+		height:    24,
+	}
+	if len(m.servers) == 1 {
+		m.servers[0].expanded = true
+	}
+	m.sort()
+	m.refresh()
+	return m
+}
+
+// selectedTokens returns the running total of the enabled components of
+// enabled servers.
+func (m *tuiModel) selectedTokens() int {
+	var total int
+	for _, s := range m.servers {
+		if !s.enabled {
+			continue
+		}
+		for _, c := range s.children {
+			if c.enabled {
+				total += c.tokens
+			}
+		}
+	}
+	return total
+}
+
+// sort orders the servers and their components by the current sort order.
+func (m *tuiModel) sort() {
+	less := func(a, b *tuiNode) bool {
+		switch tuiSortOrders[m.sortOrder] {
+		case "description":
+			if a.fieldTokens(fieldDescription) != b.fieldTokens(fieldDescription) {
+				return a.fieldTokens(fieldDescription) > b.fieldTokens(fieldDescription)
+			}
+		case "name":
+			return a.label < b.label
+		}
+		if a.tokens != b.tokens {
+			return a.tokens > b.tokens
+		}
+		return a.label < b.label
+	}
+
+	sort.SliceStable(m.servers, func(i, j int) bool { return less(m.servers[i], m.servers[j]) })
+	for _, s := range m.servers {
+		sort.SliceStable(s.children, func(i, j int) bool { return less(s.children[i], s.children[j]) })
+	}
+}
+
+// refresh recomputes the visible rows after the tree, filter or sort order
+// changed, keeping the cursor on the same node where possible.
+func (m *tuiModel) refresh() {
+	var current *tuiNode
+	if m.cursor < len(m.rows) {
+		current = m.rows[m.cursor]
+	}
+
+	m.rows = m.rows[:0]
+	for _, s := range m.servers {
+		var components []*tuiNode
+		for _, c := range s.children {
+			if !m.filter.active() || m.filter.matches(c) {
+				components = append(components, c)
+			}
+		}
+		if m.filter.active() && len(components) == 0 && !(m.filter.op == 0 && m.filter.matches(s)) {
+			continue
+		}
+
+		m.rows = append(m.rows, s)
+		if !s.expanded && !m.filter.active() {
+			continue
+		}
+		for _, c := range components {
+			m.rows = append(m.rows, c)
+			if c.expanded {
+				m.rows = append(m.rows, c.children...)
+			}
+		}
+	}
+
+	m.cursor = 0
+	for i, n := range m.rows {
+		if n == current {
+			m.cursor = i
+		}
+	}
+	m.updatePreview()
+}
+
+// selected returns the node under the cursor, or nil if no rows are visible.
+func (m *tuiModel) selected() *tuiNode {
+	if m.cursor < len(m.rows) {
+		return m.rows[m.cursor]
+	}
+	return nil
+}
+
+// Init implements tea.Model.
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.updatePreview()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.search.Focused() {
+			return m.updateSearch(msg)
+		}
+		return m.updateKey(msg)
+	}
+	return m, nil
+}
+
+// updateSearch handles keys while the search box is focused, filtering as
+// the query is typed.
+func (m *tuiModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.search.Blur()
+		return m, nil
+	case tea.KeyEsc:
+		m.search.Blur()
+		m.search.SetValue("")
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.filter = parseTUIFilter(m.search.Value())
+	m.refresh()
+	return m, cmd
+}
+
+// updateKey handles navigation and commands.
+func (m *tuiModel) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := m.selected()
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.listHeight())
+	case "pgdown":
+		m.move(m.listHeight())
+	case "home", "g":
+		m.move(-len(m.rows))
+	case "end", "G":
+		m.move(len(m.rows))
+	case "enter":
+		if n != nil && len(n.children) > 0 {
+			n.expanded = !n.expanded
+			m.refresh()
+		}
+	case "right", "l":
+		if n != nil && len(n.children) > 0 {
+			n.expanded = true
+			m.refresh()
+		}
+	case "left", "h":
+		switch {
+		case n == nil:
+		case n.expanded:
+			n.expanded = false
+			m.refresh()
+		case n.parent != nil:
+			m.cursor = slices.Index(m.rows, n.parent)
+			m.updatePreview()
+		}
+	case " ":
+		if n != nil && n.level != tuiField {
+			n.enabled = !n.enabled
+			m.updatePreview()
+		}
+	case "s":
+		m.sortOrder = (m.sortOrder + 1) % len(tuiSortOrders)
+		m.sort()
+		m.refresh()
+	case "/":
+		return m, m.search.Focus()
+	case "esc":
+		m.search.SetValue("")
+		m.filter = tuiFilter{}
+		m.refresh()
+	case "ctrl+d":
+		m.preview.HalfPageDown()
+	case "ctrl+u":
+		m.preview.HalfPageUp()
+	}
+	return m, nil
+}
+
+// move moves the cursor by delta rows, scrolling the list to keep it visible.
+func (m *tuiModel) move(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+	m.updatePreview()
+}
+
+// Layout of the screen: a title line, the list, a separator, the preview
+// and a status line.
+const tuiChromeLines = 3
+
+// listHeight returns the number of rows the list can show.
+func (m *tuiModel) listHeight() int {
+	return max(1, m.height-tuiChromeLines-m.previewHeight())
+}
+
+// previewHeight returns the number of lines of the preview pane.
+func (m *tuiModel) previewHeight() int {
+	return max(3, m.height/3)
+}
+
+// updatePreview shows the selected node in the preview pane and scrolls the
+// list to keep the cursor visible.
+func (m *tuiModel) updatePreview() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if h := m.listHeight(); m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+
+	m.preview.Width, m.preview.Height = m.width, m.previewHeight()
+	m.preview.SetContent(lipgloss.NewStyle().Width(m.width).Render(m.describe(m.selected())))
+	m.preview.GotoTop()
+}
+
+// describe renders the details of a node for the preview pane: the raw text
+// of fields, the token breakdown of components and the totals of servers.
+func (m *tuiModel) describe(n *tuiNode) string {
+	var b strings.Builder
+	switch {
+	case n == nil:
+		b.WriteString("No matching components.")
+	case n.level == tuiField:
+		fmt.Fprintf(&b, "%s %s of %s (%s tokens)\n\n%s", n.parent.kind, n.label, n.parent.label, printer.Sprintf("%d", n.tokens), n.raw)
+	case n.level == tuiComponent:
+		fmt.Fprintf(&b, "%s %s: %s tokens\n", n.kind, n.label, printer.Sprintf("%d", n.tokens))
+		for _, f := range n.children {
+			fmt.Fprintf(&b, "  %-14s %8s\n", f.label, printer.Sprintf("%d", f.tokens))
+		}
+		if desc := n.field(fieldDescription); desc != nil && desc.raw != "" {
+			fmt.Fprintf(&b, "\n%s", desc.raw)
+		}
+	default:
+		r := n.result
+		fmt.Fprintf(&b, "server %s", n.label)
+		if r.ServerInfo != nil && r.ServerInfo.Version != "" {
+			fmt.Fprintf(&b, " (version %s)", r.ServerInfo.Version)
+		}
+		if r.SourceFile != "" {
+			fmt.Fprintf(&b, "\nconfig: %s", r.SourceFile)
+		}
+		if r.Error != nil {
+			fmt.Fprintf(&b, "\n\nerror: %v", r.Error)
+			break
+		}
+		fmt.Fprintf(&b, "\n\n  %-14s %8s\n", "instructions", printer.Sprintf("%d", r.InstructionTokens))
+		fmt.Fprintf(&b, "  %-14s %8s\n", "tools", printer.Sprintf("%d", r.TotalToolTokens.TotalTokens))
+		fmt.Fprintf(&b, "  %-14s %8s\n", "prompts", printer.Sprintf("%d", r.TotalPromptTokens.TotalTokens))
+		fmt.Fprintf(&b, "  %-14s %8s\n", "resources", printer.Sprintf("%d", r.TotalResourceTokens.TotalTokens))
+		fmt.Fprintf(&b, "  %-14s %8s\n", "total", printer.Sprintf("%d", r.TotalTokens()))
+	}
+	return b.String()
+}
+
+var (
+	tuiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	tuiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	tuiDisabledStyle = lipgloss.NewStyle().Faint(true)
+	tuiOverStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+)
+
+// View implements tea.Model.
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("MCP Token Analyzer · sort: %s (s) · filter: / · toggle: space · quit: q", tuiSortOrders[m.sortOrder])
+	b.WriteString(tuiTitleStyle.Render(truncate(title, m.width)) + "\n")
+
+	h := m.listHeight()
+	for i := m.offset; i < m.offset+h; i++ {
+		if i < len(m.rows) {
+			b.WriteString(m.renderRow(m.rows[i], i == m.cursor))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+	b.WriteString(m.preview.View() + "\n")
+
+	if m.search.Focused() || m.search.Value() != "" {
+		b.WriteString(m.search.View() + "  ")
+	}
+	b.WriteString(m.renderTotal())
+	return b.String()
+}
+
+// renderRow renders a row of the list: an expansion marker, a checkbox for
+// servers and components, the label and the token count.
+func (m *tuiModel) renderRow(n *tuiNode, selected bool) string {
+	marker := " "
+	if len(n.children) > 0 {
+		marker = "▸"
+		if n.expanded || (n.level == tuiServer && m.filter.active()) {
+			marker = "▾"
+		}
+	}
+	check := "   "
+	if n.level != tuiField {
+		check = "[ ]"
+		if n.enabled {
+			check = "[x]"
+		}
+	}
+
+	label := n.label
+	switch {
+	case n.level == tuiComponent:
+		label = n.kind + " " + label
+	case n.level == tuiServer && n.result.Error != nil:
+		label += " (error)"
+	}
+
+	tokens := printer.Sprintf("%d", n.tokens)
+	width := max(10, m.width-len(tokens)-1)
+	left := truncate(fmt.Sprintf("%s%s %s %s", strings.Repeat("  ", n.level), marker, check, label), width)
+	row := left + strings.Repeat(" ", width-utf8.RuneCountInString(left)+1) + tokens
+
+	switch {
+	case selected:
+		return tuiSelectedStyle.Render(row)
+	case !effective(n):
+		return tuiDisabledStyle.Render(row)
+	}
+	return row
+}
+
+// effective reports whether a node counts toward the running total: it and
+// the server and component it belongs to are enabled.
+func effective(n *tuiNode) bool {
+	for ; n != nil; n = n.parent {
+		if n.level != tuiField && !n.enabled {
+			return false
+		}
+	}
+	return true
+}
+
+// renderTotal renders the running total of the enabled components, against
+// --limit if set and with its estimated cost if enabled.
+func (m *tuiModel) renderTotal() string {
+	total := m.selectedTokens()
+	var costs string
+	if m.estimator != nil {
+		costs = fmt.Sprintf(" · %s/day, %s/month", formatCost(m.estimator.Daily(total)), formatCost(m.estimator.Monthly(total)))
+	}
+	if m.limit <= 0 {
+		return fmt.Sprintf("Selected: %s tokens", printer.Sprintf("%d", total)) + costs
+	}
+
+	s := fmt.Sprintf("Selected: %s / %s tokens (%.1f%%)", printer.Sprintf("%d", total), printer.Sprintf("%d", m.limit), float64(total)/float64(m.limit)*100) + costs
+	if total > m.limit {
+		return tuiOverStyle.Render(s)
+	}
+	return s
+}
+
+// truncate shortens s to at most width runes.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(r[:max(0, width)])
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+func testTUIReport() *analyzer.Report {
+	return &analyzer.Report{Servers: []*analyzer.ServerResult{
+		{
+			Name:              "docs",
+			Instructions:      "Search the docs.",
+			InstructionTokens: 5,
+			TotalToolTokens:   analyzer.ToolTokens{TotalTokens: 130},
+			ToolStats: []analyzer.ToolTokens{
+				{Name: "search", NameTokens: 1, DescTokens: 9, SchemaTokens: 20, TotalTokens: 30},
+				{Name: "delete", NameTokens: 1, DescTokens: 79, SchemaTokens: 20, TotalTokens: 100},
+			},
+			Tools: []*mcp.Tool{
+				{Name: "search", Description: "Search the docs"},
+				{Name: "delete", Description: "Delete a page"},
+			},
+			EffectiveToolTokens: analyzer.ToolTokens{TotalTokens: 30},
+			ExcludedTools:       map[string]bool{"delete": true},
+		},
+		{Name: "broken", Error: errors.New("connection refused")},
+	}}
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func press(m *tuiModel, keys ...string) {
+	for _, k := range keys {
+		m.Update(key(k))
+	}
+}
+
+func labels(m *tuiModel) []string {
+	var got []string
+	for _, n := range m.rows {
+		got = append(got, n.label)
+	}
+	return got
+}
+
+func TestTUIModel_RunningTotal(t *testing.T) {
+	report := testTUIReport()
+	m := newTUIModel(report, 100, nil)
+
+	if got, want := m.selectedTokens(), report.EffectiveTokens(); got != want {
+		t.Fatalf("expected the initial total to be the effective footprint %d, got %d", want, got)
+	}
+
+	// Servers are sorted by total: docs first. Expand it and enable the
+	// excluded delete tool, which sorts first by total.
+	press(m, "enter", "j", " ")
+	if n := m.selected(); n.label != "delete" || !n.enabled {
+		t.Fatalf("expected delete to be selected and enabled, got %s (enabled %v)", n.label, n.enabled)
+	}
+	if got := m.selectedTokens(); got != 135 {
+		t.Errorf("expected 135 tokens with delete enabled, got %d", got)
+	}
+
+	// Disabling the server removes all its components.
+	press(m, "h", " ")
+	if got := m.selectedTokens(); got != 0 {
+		t.Errorf("expected 0 tokens with the server disabled, got %d", got)
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if view := m.View(); !strings.Contains(view, "Selected: 0 / 100 tokens (0.0%)") {
+		t.Errorf("expected the running total against the limit in the view, got:\n%s", view)
+	}
+}
+
+func TestTUIModel_Cost(t *testing.T) {
+	estimator, err := cost.NewEstimator(cost.Pricing{"gpt-4o": {Input: 2.5}}, "gpt-4o", cost.Usage{RequestsPerDay: 1_000_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTUIModel(testTUIReport(), 0, estimator)
+
+	total := m.selectedTokens()
+	want := fmt.Sprintf("Selected: %d tokens · %s/day, %s/month", total, formatCost(estimator.Daily(total)), formatCost(estimator.Monthly(total)))
+	if got := m.renderTotal(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTUIModel_Navigation(t *testing.T) {
+	m := newTUIModel(testTUIReport(), 0, nil)
+
+	press(m, "enter")
+	if got, want := labels(m), []string{"docs", "delete", "search", "instructions", "broken"}; !slices.Equal(got, want) {
+		t.Fatalf("expected rows %v, got %v", want, got)
+	}
+
+	press(m, "j", "l")
+	if got, want := labels(m), []string{"docs", "delete", fieldName, fieldDescription, fieldInputSchema, "search", "instructions", "broken"}; !slices.Equal(got, want) {
+		t.Fatalf("expected rows %v, got %v", want, got)
+	}
+
+	press(m, "j", "j")
+	if n := m.selected(); n.label != fieldDescription || n.raw != "Delete a page" {
+		t.Errorf("expected the description of delete, got %s %q", n.label, n.raw)
+	}
+
+	press(m, "h")
+	if n := m.selected(); n.label != "delete" {
+		t.Errorf("expected h to move to the parent, got %s", n.label)
+	}
+
+	press(m, "s")
+	if got, want := labels(m), []string{"docs", "delete", fieldName, fieldDescription, fieldInputSchema, "search", "instructions", "broken"}; !slices.Equal(got, want) {
+		t.Errorf("expected rows sorted by description tokens %v, got %v", want, got)
+	}
+	press(m, "s")
+	if got, want := labels(m), []string{"broken", "docs", "delete", fieldName, fieldDescription, fieldInputSchema, "instructions", "search"}; !slices.Equal(got, want) {
+		t.Errorf("expected rows sorted by name %v, got %v", want, got)
+	}
+}
+
+func TestTUIModel_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "name", query: "sea", want: []string{"docs", "search"}},
+		{name: "server_name", query: "broken", want: []string{"broken"}},
+		{name: "more_tokens", query: ">20", want: []string{"docs", "delete", "search"}},
+		{name: "fewer_tokens", query: "<10", want: []string{"docs", "instructions"}},
+		{name: "no_match", query: "nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTUIModel(testTUIReport(), 0, nil)
+			press(m, "/")
+			for _, r := range tt.query {
+				press(m, string(r))
+			}
+			press(m, "enter")
+
+			if got := labels(m); !slices.Equal(got, tt.want) {
+				t.Errorf("expected rows %v, got %v", tt.want, got)
+			}
+
+			press(m, "esc")
+			if got := labels(m); len(got) != 2 {
+				t.Errorf("expected esc to clear the search, got rows %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// watch.go contains --watch mode, which keeps server sessions open and
+// re-renders the analysis whenever servers or their config files change.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/cost"
+)
+
+// watchSettleDelay is how long to wait for further file events before
+// restarting, since editors and builds often write a file several times in
+// quick succession.
+const watchSettleDelay = 250 * time.Millisecond
+
+// runWatch analyzes the configured servers and keeps their sessions open,
+// re-rendering the tables whenever a server reports changed tools, prompts
+// or resources. When a config file (or, with --watch.binaries, the
+// executable of a stdio server) changes, the config is reloaded and all
+// servers are reconnected. Costs are estimated if est is not nil. It returns
+// once ctx is done.
+func runWatch(ctx context.Context, a *analyzer.Analyzer, est *cost.Estimator, resolveInput config.InputResolver) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch files: %w", err)
+	}
+	defer watcher.Close()
+
+	var (
+		prev    *analyzer.Report
+		reports = make(chan *analyzer.Report)
+	)
+	for first := true; ; first = false {
+		servers, err := loadServers(resolveInput)
+		if err != nil && first {
+			return err
+		}
+
+		stop := func() {}
+		if err != nil {
+			// Keep watching the config files for a fix.
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			stop = startWatch(ctx, a, servers, reports)
+		}
+
+		files := watchedFiles(servers)
+		if err := watchFiles(watcher, files); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		var settle <-chan time.Time
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				stop()
+				return nil
+			case r := <-reports:
+				renderWatchUpdate(r, prev, est)
+				prev = r
+			case ev := <-watcher.Events:
+				if files[filepath.Clean(ev.Name)] && !ev.Has(fsnotify.Chmod) {
+					settle = time.After(watchSettleDelay)
+				}
+			case err := <-watcher.Errors:
+				fmt.Fprintf(os.Stderr, "Warning: error watching files: %v\n", err)
+			case <-settle:
+				break loop
+			}
+		}
+		stop()
+	}
+}
+
+// startWatch starts watching servers in the background, sending each report
+// to reports. The returned function stops watching and waits until all
+// sessions are closed.
+func startWatch(ctx context.Context, a *analyzer.Analyzer, servers map[string]*config.ServerConfig, reports chan<- *analyzer.Report) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Watch(ctx, servers, func(r *analyzer.Report) {
+			select {
+			case reports <- r:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// loadServers loads the config and selects the servers to analyze, the same
+// way as a one-shot analysis.
+func loadServers(resolveInput config.InputResolver) (map[string]*config.ServerConfig, error) {
+	cfg, err := loadOrBuildConfig()
+	if err != nil {
+		return nil, err
+	}
+	return selectServers(cfg, resolveInput)
+}
+
+// watchedFiles returns the cleaned absolute paths of the files whose changes
+// restart the analysis: the --config files, the files servers were loaded
+// from and, with --watch.binaries, the executables of stdio servers.
+func watchedFiles(servers map[string]*config.ServerConfig) map[string]bool {
+	files := make(map[string]bool)
+	add := func(path string) {
+		if abs, err := filepath.Abs(path); err == nil {
+			files[abs] = true
+		}
+	}
+
+	for _, path := range *flagConfigFiles {
+		add(path)
+	}
+	for _, srv := range servers {
+		if srv.Source.File != "" {
+			add(srv.Source.File)
+		}
+		if *flagWatchBinaries && srv.Type == config.TransportStdio {
+			if path, ok := serverExecutable(srv); ok {
+				add(path)
+			}
+		}
+	}
+	return files
+}
+
+// serverExecutable resolves the executable a stdio server's command runs,
+// the way exec.Command does: commands without a path separator are looked
+// up in PATH, and relative paths resolve against the server's working
+// directory.
+func serverExecutable(srv *config.ServerConfig) (string, bool) {
+	if filepath.Base(srv.Command) == srv.Command {
+		path, err := exec.LookPath(srv.Command)
+		return path, err == nil
+	}
+	if filepath.IsAbs(srv.Command) {
+		return srv.Command, true
+	}
+
+	dir := srv.Cwd
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(srv.Dir(), dir)
+	}
+	return filepath.Join(dir, srv.Command), true
+}
+
+// watchFiles makes watcher watch the directories of files, and nothing else.
+// Directories are watched rather than the files themselves so that files
+// replaced by a rename (as many editors save) keep being watched.
+func watchFiles(watcher *fsnotify.Watcher, files map[string]bool) error {
+	dirs := make(map[string]bool)
+	for path := range files {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	for _, dir := range watcher.WatchList() {
+		if !dirs[dir] {
+			_ = watcher.Remove(dir)
+		}
+	}
+
+	var errs []error
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to watch %s: %w", dir, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// renderWatchUpdate renders the latest report of --watch mode, with changes
+// since prev (if not nil) in Change columns. On a terminal, the previous
+// output is cleared first.
+func renderWatchUpdate(report, prev *analyzer.Report, est *cost.Estimator) {
+	if highlightChanges {
+		fmt.Print(clearScreen)
+	}
+	fmt.Printf("Watching %d servers, updated %s (press Ctrl+C to stop)\n", len(report.Servers), time.Now().Format(time.TimeOnly))
+
+	var prevServers []*analyzer.ServerResult
+	if prev != nil {
+		prevServers = prev.Servers
+	}
+
+	if len(report.Servers) == 1 || *flagDetail {
+		renderDetailTables(report.Servers, prevServers)
+	}
+	renderSummary("Token Analysis Summary", report.Servers, prevServers, est)
+}
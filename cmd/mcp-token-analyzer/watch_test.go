@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+func TestServerExecutable(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "mcp-server")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name   string
+		srv    config.ServerConfig
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "looked_up_in_path",
+			srv:    config.ServerConfig{Command: "mcp-server"},
+			want:   bin,
+			wantOK: true,
+		},
+		{
+			name: "not_in_path",
+			srv:  config.ServerConfig{Command: "missing-server"},
+		},
+		{
+			name:   "absolute",
+			srv:    config.ServerConfig{Command: "/opt/mcp/server"},
+			want:   "/opt/mcp/server",
+			wantOK: true,
+		},
+		{
+			name:   "relative_to_cwd",
+			srv:    config.ServerConfig{Command: "./bin/server", Cwd: "/srv/mcp"},
+			want:   "/srv/mcp/bin/server",
+			wantOK: true,
+		},
+		{
+			name:   "relative_cwd_resolves_against_config_dir",
+			srv:    config.ServerConfig{Command: "./server", Cwd: "tools", Source: config.Source{File: "/home/user/project/.mcp.json"}},
+			want:   "/home/user/project/tools/server",
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := serverExecutable(&tt.srv)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
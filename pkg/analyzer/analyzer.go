@@ -1,9 +1,13 @@
 // Package analyzer provides token counting and analysis functionality for MCP
 // server artifacts including tools, prompts, and resources. It uses tiktoken
 // encoding to count tokens compatible with various LLM models.
+//
+// AnalyzeClient analyzes a connected MCP server as a whole, and
+// AnalyzeServer does so in-process for servers built with the go-sdk.
 package analyzer
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -84,17 +88,18 @@ type TokenCounter struct {
 }
 
 // NewTokenCounter creates a TokenCounter using the encoding for the specified model.
-// If model is empty, it uses defaultTokenEncoding.
+// The model may also name an encoding directly. If model is empty, it uses
+// defaultTokenEncoding.
 func NewTokenCounter(model string) (*TokenCounter, error) {
 	var (
 		encoder *tiktoken.Tiktoken
 		err     error
 	)
 
-	if model != "" {
-		encoder, err = tiktoken.EncodingForModel(model)
+	if _, isEncoding := tiktokenEncodings[model]; isEncoding || model == "" {
+		encoder, err = tiktoken.GetEncoding(cmp.Or(model, defaultTokenEncoding))
 	} else {
-		encoder, err = tiktoken.GetEncoding(defaultTokenEncoding)
+		encoder, err = tiktoken.EncodingForModel(model)
 	}
 
 	if err != nil {
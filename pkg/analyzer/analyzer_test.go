@@ -397,3 +397,19 @@ func TestNewTokenCounter_InvalidModel(t *testing.T) {
 		t.Error("NewTokenCounter() with invalid model should return error")
 	}
 }
+
+func TestNewTokenCounter_Encoding(t *testing.T) {
+	byEncoding, err := NewTokenCounter("cl100k_base")
+	if err != nil {
+		t.Fatalf("failed to create counter for encoding: %v", err)
+	}
+	byModel, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter for model: %v", err)
+	}
+
+	text := "Encoding names select the encoding directly."
+	if got, want := byEncoding.CountTokens(text), byModel.CountTokens(text); got != want {
+		t.Errorf("expected cl100k_base to count %d tokens like gpt-4, got %d", want, got)
+	}
+}
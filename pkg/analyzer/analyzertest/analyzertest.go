@@ -0,0 +1,315 @@
+// Package analyzertest provides helpers for asserting the token footprint of
+// MCP servers built with the go-sdk from their own tests. Servers are
+// analyzed in-process with analyzer.AnalyzeServer:
+//
+//	func TestTokenBudget(t *testing.T) {
+//		server := newServer()
+//		analyzertest.RequireToolUnder(t, server, "query", 200)
+//		analyzertest.RequireTotalUnder(t, server, 2000)
+//		analyzertest.RequireGolden(t, server, "testdata/tokens.golden")
+//	}
+//
+// Each server is analyzed once per test: the helpers share the result, so
+// make any changes to a server before the first helper is called on it.
+//
+// Golden files record the token counts of every tool. Run the tests with
+// ANALYZERTEST_UPDATE=1 in the environment to create or update them after an
+// intended change.
+package analyzertest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// Model selects the tokenizer model used by the helpers, as accepted by
+// analyzer.NewTokenCounter. The empty default uses the cl100k_base encoding.
+// Set it before calling any helper, e.g. in TestMain.
+var Model string
+
+// updateEnv names the environment variable that makes RequireGolden write
+// golden files instead of comparing against them. An environment variable
+// rather than a flag works with go test ./..., where packages that don't
+// import analyzertest would reject an unknown flag.
+const updateEnv = "ANALYZERTEST_UPDATE"
+
+// updating reports whether golden files should be written.
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(updateEnv))
+	return update
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = make(map[string]*analyzer.TokenCounter)
+)
+
+// counter returns the shared TokenCounter for Model. Loading an encoding is
+// expensive, so counters are created once per model.
+func counter(t testing.TB) *analyzer.TokenCounter {
+	t.Helper()
+
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	if c, ok := counters[Model]; ok {
+		return c
+	}
+	c, err := analyzer.NewTokenCounter(Model)
+	if err != nil {
+		t.Fatalf("analyzertest: %v", err)
+	}
+	counters[Model] = c
+	return c
+}
+
+// resultKey identifies a cached analysis.
+type resultKey struct {
+	test   string
+	server *mcp.Server
+	model  string
+}
+
+var (
+	resultsMu sync.Mutex
+	results   = make(map[resultKey]*analyzer.ServerResult)
+)
+
+// analyze returns the analysis of server for the current test, analyzing it
+// on first use. Cached results are dropped when the test finishes.
+func analyze(t testing.TB, server *mcp.Server) *analyzer.ServerResult {
+	t.Helper()
+
+	key := resultKey{test: t.Name(), server: server, model: Model}
+	resultsMu.Lock()
+	result, ok := results[key]
+	resultsMu.Unlock()
+	if ok {
+		return result
+	}
+
+	result = Analyze(t, server)
+
+	resultsMu.Lock()
+	defer resultsMu.Unlock()
+	if _, ok := results[key]; !ok {
+		t.Cleanup(func() {
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			delete(results, key)
+		})
+	}
+	results[key] = result
+	return result
+}
+
+// Analyze analyzes server in-process and returns the result, failing the
+// test if the server could not be analyzed. Unlike the Require helpers, it
+// analyzes the server anew on every call.
+func Analyze(t testing.TB, server *mcp.Server) *analyzer.ServerResult {
+	t.Helper()
+
+	result, err := analyzer.AnalyzeServer(t.Context(), server, counter(t))
+	if err == nil {
+		err = result.Error
+	}
+	if err != nil {
+		t.Fatalf("analyzertest: failed to analyze server: %v", err)
+	}
+	return result
+}
+
+// RequireToolUnder fails the test if the server has no tool with the given
+// name or if the tool's definition takes more than limit tokens.
+func RequireToolUnder(t testing.TB, server *mcp.Server, tool string, limit int) {
+	t.Helper()
+
+	result := analyze(t, server)
+	stats, ok := result.Tool(tool)
+	if !ok {
+		t.Fatalf("analyzertest: server %q has no tool %q", result.Name, tool)
+	}
+	if stats.TotalTokens > limit {
+		t.Errorf("tool %q uses %d tokens, over the limit of %d by %d (%s)",
+			tool, stats.TotalTokens, limit, stats.TotalTokens-limit, formatBreakdown(stats))
+	}
+}
+
+// RequireTotalUnder fails the test if the server's instructions, tools,
+// prompts and resources take more than limit tokens in total.
+func RequireTotalUnder(t testing.TB, server *mcp.Server, limit int) {
+	t.Helper()
+
+	result := analyze(t, server)
+	if total := result.TotalTokens(); total > limit {
+		t.Errorf("server %q uses %d tokens, over the limit of %d by %d (instructions %d, tools %d, prompts %d, resources %d)",
+			result.Name, total, limit, total-limit, result.InstructionTokens,
+			result.TotalToolTokens.TotalTokens, result.TotalPromptTokens.TotalTokens, result.TotalResourceTokens.TotalTokens)
+	}
+}
+
+// RequireGolden compares the per-tool token counts of server against the
+// golden file at path and fails the test with a diff of the changed tools if
+// they differ. With ANALYZERTEST_UPDATE=1, the golden file is written instead.
+func RequireGolden(t testing.TB, server *mcp.Server, path string) {
+	t.Helper()
+
+	got := analyze(t, server).ToolStats
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("analyzertest: failed to create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(formatGolden(got)), 0o644); err != nil {
+			t.Fatalf("analyzertest: failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("analyzertest: golden file %s does not exist; run the test with %s=1 to create it", path, updateEnv)
+	}
+	if err != nil {
+		t.Fatalf("analyzertest: failed to read golden file: %v", err)
+	}
+	want, err := parseGolden(string(data))
+	if err != nil {
+		t.Fatalf("analyzertest: invalid golden file %s: %v", path, err)
+	}
+
+	if diff := diffTools(want, got); diff != "" {
+		t.Errorf("tool token counts differ from %s (-golden +current):\n%s\nRun the test with %s=1 if the change is intended.", path, diff, updateEnv)
+	}
+}
+
+// goldenHeader starts every golden file and documents its columns.
+const goldenHeader = "# tool: total name description inputSchema outputSchema annotations\n"
+
+// formatBreakdown describes the components of a tool's token count.
+func formatBreakdown(t analyzer.ToolTokens) string {
+	return fmt.Sprintf("name %d, description %d, input schema %d, output schema %d, annotations %d",
+		t.NameTokens, t.DescTokens, t.SchemaTokens, t.OutputSchemaTokens, t.AnnotationsTokens)
+}
+
+// formatTool formats a tool as a golden file line, without a newline.
+func formatTool(t analyzer.ToolTokens) string {
+	return fmt.Sprintf("%s: %d %d %d %d %d %d",
+		t.Name, t.TotalTokens, t.NameTokens, t.DescTokens, t.SchemaTokens, t.OutputSchemaTokens, t.AnnotationsTokens)
+}
+
+// formatGolden formats tools as a golden file, one line per tool, sorted by
+// name.
+func formatGolden(tools []analyzer.ToolTokens) string {
+	sorted := sortedTools(tools)
+
+	var b strings.Builder
+	b.WriteString(goldenHeader)
+	for _, t := range sorted {
+		b.WriteString(formatTool(t))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// parseGolden parses a golden file written by formatGolden. Blank lines and
+// lines starting with '#' are ignored.
+func parseGolden(data string) ([]analyzer.ToolTokens, error) {
+	var tools []analyzer.ToolTokens
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Tool names may not contain spaces, but may contain colons, so
+		// split at the last one.
+		sep := strings.LastIndex(line, ":")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: missing ':' after tool name", i+1)
+		}
+		fields := strings.Fields(line[sep+1:])
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("line %d: expected 6 token counts, got %d", i+1, len(fields))
+		}
+		counts := make([]int, len(fields))
+		for j, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid token count %q", i+1, f)
+			}
+			counts[j] = n
+		}
+
+		tools = append(tools, analyzer.ToolTokens{
+			Name:               line[:sep],
+			TotalTokens:        counts[0],
+			NameTokens:         counts[1],
+			DescTokens:         counts[2],
+			SchemaTokens:       counts[3],
+			OutputSchemaTokens: counts[4],
+			AnnotationsTokens:  counts[5],
+		})
+	}
+	return tools, nil
+}
+
+// diffTools returns a line diff of the tools that were added, removed or
+// changed between want and got, or "" if they match. Changed tools are
+// annotated with the change in total tokens.
+func diffTools(want, got []analyzer.ToolTokens) string {
+	wantByName := make(map[string]analyzer.ToolTokens, len(want))
+	for _, t := range want {
+		wantByName[t.Name] = t
+	}
+	gotByName := make(map[string]analyzer.ToolTokens, len(got))
+	for _, t := range got {
+		gotByName[t.Name] = t
+	}
+
+	names := make([]string, 0, len(wantByName)+len(gotByName))
+	for name := range wantByName {
+		names = append(names, name)
+	}
+	for name := range gotByName {
+		if _, ok := wantByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		w, inWant := wantByName[name]
+		g, inGot := gotByName[name]
+		switch {
+		case !inGot:
+			fmt.Fprintf(&b, "- %s (tool removed)\n", formatTool(w))
+		case !inWant:
+			fmt.Fprintf(&b, "+ %s (new tool)\n", formatTool(g))
+		case w != g:
+			fmt.Fprintf(&b, "- %s\n+ %s (%+d tokens)\n", formatTool(w), formatTool(g), g.TotalTokens-w.TotalTokens)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sortedTools returns a copy of tools sorted by name.
+func sortedTools(tools []analyzer.ToolTokens) []analyzer.ToolTokens {
+	sorted := append([]analyzer.ToolTokens(nil), tools...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
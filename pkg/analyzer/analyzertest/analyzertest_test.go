@@ -0,0 +1,208 @@
+package analyzertest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// recorder is a testing.TB that records failures instead of failing the
+// test, so that the helpers' failure paths can be tested.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls fn with a recorder in a separate goroutine, so that Fatalf can
+// stop it, and returns the recorded failures.
+func run(t *testing.T, fn func(testing.TB)) []string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+	return r.failures
+}
+
+func newTestServer() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "inventory"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "query", Description: "Run a read-only SQL query against the inventory database"},
+		func(context.Context, *mcp.CallToolRequest, struct {
+			SQL string `json:"sql"`
+		}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	return server
+}
+
+func TestRequireToolUnder(t *testing.T) {
+	server := newTestServer()
+
+	if failures := run(t, func(tb testing.TB) { RequireToolUnder(tb, server, "query", 10000) }); len(failures) != 0 {
+		t.Errorf("expected no failures under a generous limit, got %v", failures)
+	}
+	if failures := run(t, func(tb testing.TB) { RequireToolUnder(tb, server, "query", 1) }); len(failures) != 1 || !strings.Contains(failures[0], "over the limit of 1") {
+		t.Errorf("expected a limit failure, got %v", failures)
+	}
+	if failures := run(t, func(tb testing.TB) { RequireToolUnder(tb, server, "missing", 10000) }); len(failures) != 1 || !strings.Contains(failures[0], "has no tool") {
+		t.Errorf("expected a missing tool failure, got %v", failures)
+	}
+}
+
+func TestRequireTotalUnder(t *testing.T) {
+	server := newTestServer()
+
+	if failures := run(t, func(tb testing.TB) { RequireTotalUnder(tb, server, 10000) }); len(failures) != 0 {
+		t.Errorf("expected no failures under a generous limit, got %v", failures)
+	}
+	if failures := run(t, func(tb testing.TB) { RequireTotalUnder(tb, server, 1) }); len(failures) != 1 || !strings.Contains(failures[0], `server "inventory"`) {
+		t.Errorf("expected a limit failure, got %v", failures)
+	}
+}
+
+func TestRequireGolden(t *testing.T) {
+	t.Setenv(updateEnv, "")
+	path := filepath.Join(t.TempDir(), "tokens.golden")
+	server := newTestServer()
+
+	if failures := run(t, func(tb testing.TB) { RequireGolden(tb, server, path) }); len(failures) != 1 || !strings.Contains(failures[0], "does not exist") {
+		t.Fatalf("expected a missing golden file failure, got %v", failures)
+	}
+
+	t.Setenv(updateEnv, "1")
+	failures := run(t, func(tb testing.TB) { RequireGolden(tb, server, path) })
+	t.Setenv(updateEnv, "")
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures writing golden file: %v", failures)
+	}
+
+	if failures := run(t, func(tb testing.TB) { RequireGolden(tb, server, path) }); len(failures) != 0 {
+		t.Errorf("expected a match against the written golden file, got %v", failures)
+	}
+
+	// Inflate the tool's description; the golden file must catch it. The
+	// analysis of server is cached for this test, so use a new server.
+	inflated := newTestServer()
+	mcp.AddTool(inflated, &mcp.Tool{Name: "query", Description: strings.Repeat("Run a read-only SQL query. ", 10)},
+		func(context.Context, *mcp.CallToolRequest, struct {
+			SQL string `json:"sql"`
+		}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	failures = run(t, func(tb testing.TB) { RequireGolden(tb, inflated, path) })
+	if len(failures) != 1 || !strings.Contains(failures[0], "+ query:") {
+		t.Errorf("expected a diff for the inflated tool, got %v", failures)
+	}
+}
+
+func TestHelpers_AnalyzeOncePerTest(t *testing.T) {
+	var initializations atomic.Int32
+	server := newTestServer()
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "initialize" {
+				initializations.Add(1)
+			}
+			return next(ctx, method, req)
+		}
+	})
+
+	RequireToolUnder(t, server, "query", 10000)
+	RequireTotalUnder(t, server, 10000)
+	if n := initializations.Load(); n != 1 {
+		t.Errorf("expected the helpers to share one analysis, got %d connections", n)
+	}
+
+	t.Run("subtest", func(t *testing.T) {
+		RequireTotalUnder(t, server, 10000)
+	})
+	if n := initializations.Load(); n != 2 {
+		t.Errorf("expected a subtest to analyze the server anew, got %d connections", n)
+	}
+}
+
+func TestGoldenRoundTrip(t *testing.T) {
+	tools := []analyzer.ToolTokens{
+		{Name: "search", TotalTokens: 60, NameTokens: 1, DescTokens: 20, SchemaTokens: 39},
+		{Name: "ns:query", TotalTokens: 120, NameTokens: 3, DescTokens: 30, SchemaTokens: 70, OutputSchemaTokens: 10, AnnotationsTokens: 7},
+	}
+
+	data := formatGolden(tools)
+	if !strings.HasPrefix(data, goldenHeader) || !strings.Contains(data, "ns:query: 120 3 30 70 10 7\n") {
+		t.Errorf("unexpected golden file:\n%s", data)
+	}
+
+	parsed, err := parseGolden(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := diffTools(tools, parsed); diff != "" {
+		t.Errorf("round trip changed tools:\n%s", diff)
+	}
+}
+
+func TestParseGolden_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"missing_colon", "query 1 2 3 4 5 6\n"},
+		{"too_few_counts", "query: 1 2 3\n"},
+		{"not_a_number", "query: 1 2 3 4 5 x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseGolden(tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDiffTools(t *testing.T) {
+	want := []analyzer.ToolTokens{
+		{Name: "query", TotalTokens: 100, DescTokens: 40},
+		{Name: "removed", TotalTokens: 10},
+		{Name: "same", TotalTokens: 5},
+	}
+	got := []analyzer.ToolTokens{
+		{Name: "query", TotalTokens: 125, DescTokens: 65},
+		{Name: "added", TotalTokens: 20},
+		{Name: "same", TotalTokens: 5},
+	}
+
+	wantDiff := strings.Join([]string{
+		"+ added: 20 0 0 0 0 0 (new tool)",
+		"- query: 100 0 40 0 0 0",
+		"+ query: 125 0 65 0 0 0 (+25 tokens)",
+		"- removed: 10 0 0 0 0 0 (tool removed)",
+	}, "\n")
+	if diff := diffTools(want, got); diff != wantDiff {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, wantDiff)
+	}
+
+	if diff := diffTools(want, want); diff != "" {
+		t.Errorf("expected no diff for identical tools, got:\n%s", diff)
+	}
+}
@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/mcpclient"
+)
+
+// AnalyzeServer analyzes an MCP server in-process, connecting to it through
+// the SDK's in-memory transport. It is meant for servers built with the
+// go-sdk, e.g. to assert token budgets from the server's own tests:
+//
+//	server := mcp.NewServer(&mcp.Implementation{Name: "example"}, nil)
+//	mcp.AddTool(server, &mcp.Tool{Name: "query", Description: "..."}, handler)
+//	result, err := analyzer.AnalyzeServer(ctx, server, counter)
+//
+// The result is the same as for servers analyzed by the CLI and is named
+// after the server's implementation name. Errors while listing components
+// are reported in result.Error, and components that could not be analyzed
+// in result.Warnings; the returned error is only set if the server could
+// not be connected to.
+func AnalyzeServer(ctx context.Context, server *mcp.Server, counter *TokenCounter) (*ServerResult, error) {
+	client, err := mcpclient.NewInMemoryClient(ctx, server, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	result := AnalyzeClient(ctx, client, counter)
+	if initResp := client.InitializeResult(); initResp != nil && initResp.ServerInfo != nil {
+		result.Name = initResp.ServerInfo.Name
+	}
+	return result, nil
+}
+
+// AnalyzeClient performs the analysis on an already-connected client. The
+// result is unnamed and unfiltered: its effective footprint equals the full
+// footprint.
+func AnalyzeClient(ctx context.Context, client *mcpclient.Client, counter *TokenCounter) *ServerResult {
+	result := &ServerResult{}
+
+	initResp := client.InitializeResult()
+	if initResp == nil {
+		result.Error = errors.New("MCP session not initialized")
+		return result
+	}
+
+	result.ServerInfo = initResp.ServerInfo
+
+	// Instructions
+	result.Instructions = initResp.Instructions
+	result.InstructionTokens = counter.CountTokens(initResp.Instructions)
+
+	// Only analyze components the server advertises support for. This
+	// avoids noisy "Method not found" warnings from servers that don't
+	// implement all capability types.
+	caps := initResp.Capabilities
+	listings := []struct {
+		component  string
+		advertised bool
+		analyze    func(context.Context, *mcpclient.Client, *TokenCounter, *ServerResult) error
+	}{
+		{"tools", caps != nil && caps.Tools != nil, analyzeTools},
+		{"prompts", caps != nil && caps.Prompts != nil, analyzePrompts},
+		{"resources", caps != nil && caps.Resources != nil, analyzeResources},
+		{"resource templates", caps != nil && caps.Resources != nil, analyzeResourceTemplates},
+	}
+	for _, l := range listings {
+		if !l.advertised {
+			continue
+		}
+		err := withRequestTimeout(ctx, client, l.component, func(ctx context.Context) error {
+			return l.analyze(ctx, client, counter, result)
+		})
+		// Servers may advertise a capability without implementing every
+		// list method of it (resource templates in particular). Keep what
+		// the other listings found rather than failing the server.
+		if isMethodNotFound(err) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("server does not implement listing %s: %v", l.component, err))
+			continue
+		}
+		if err != nil {
+			result.Error = err
+			break
+		}
+	}
+
+	result.EffectiveToolTokens = result.TotalToolTokens
+	result.Retries = client.Retries()
+
+	return result
+}
+
+// withRequestTimeout runs a component listing bounded by the client's request
+// timeout. A listing that fails, or runs out of time because the server has
+// stopped responding, is returned as an error for the whole server: a
+// partial listing would understate the server's footprint. Callers treat
+// a listing the server does not implement as a warning instead. The error
+// includes the tail of a stdio server's stderr.
+func withRequestTimeout(ctx context.Context, client *mcpclient.Client, component string, list func(context.Context) error) error {
+	listCtx, cancel := client.WithRequestTimeout(ctx)
+	defer cancel()
+
+	err := list(listCtx)
+
+	if errors.Is(listCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return client.WithStderr(fmt.Errorf("listing %s timed out after %s", component, client.RequestTimeout))
+	}
+	if err != nil {
+		return client.WithStderr(fmt.Errorf("failed to list %s: %w", component, err))
+	}
+	return nil
+}
+
+// isMethodNotFound reports whether err is a JSON-RPC "Method not found"
+// error returned by the server.
+func isMethodNotFound(err error) bool {
+	var wireErr *jsonrpc.Error
+	return errors.As(err, &wireErr) && wireErr.Code == jsonrpc.CodeMethodNotFound
+}
+
+// addAnalysisWarning records a component that could not be analyzed and was
+// left out of the result.
+func addAnalysisWarning(result *ServerResult, componentType, name string, err error) {
+	result.Warnings = append(result.Warnings, fmt.Sprintf("failed to analyze %s %s: %v", componentType, name, err))
+}
+
+// analyzeTools lists and analyzes all tools from the server.
+// Fills in the tool definitions, per-tool stats and accumulated totals of result.
+// Uses the client's paginating iterator to ensure all tools are retrieved.
+// Callers should check server capabilities before calling this function.
+// Returns the error of a failed listing; components that fail to analyze are
+// recorded in result.Warnings instead.
+//
+// All tools are analyzed, including ones the client would hide from the
+// model, so that both the full and effective footprints can be derived from
+// the stats afterwards.
+func analyzeTools(ctx context.Context, client *mcpclient.Client, counter *TokenCounter, result *ServerResult) error {
+	result.TotalToolTokens = ToolTokens{Name: TotalLabel}
+
+	for tool, err := range client.Tools(ctx, nil) {
+		if err != nil {
+			return err
+		}
+		toolStats, err := counter.AnalyzeTool(tool)
+		if err != nil {
+			addAnalysisWarning(result, "tool", tool.Name, err)
+			continue
+		}
+		result.Tools = append(result.Tools, tool)
+		result.ToolStats = append(result.ToolStats, toolStats)
+		result.TotalToolTokens.Add(toolStats)
+	}
+	return nil
+}
+
+// analyzePrompts lists and analyzes all prompts from the server.
+// Fills in the prompt definitions, per-prompt stats and accumulated totals of result.
+// Uses the client's paginating iterator to ensure all prompts are retrieved.
+// Callers should check server capabilities before calling this function.
+// Returns the error of a failed listing; components that fail to analyze are
+// recorded in result.Warnings instead.
+func analyzePrompts(ctx context.Context, client *mcpclient.Client, counter *TokenCounter, result *ServerResult) error {
+	result.TotalPromptTokens = PromptTokens{Name: TotalLabel}
+
+	for prompt, err := range client.Prompts(ctx, nil) {
+		if err != nil {
+			return err
+		}
+		promptStats, err := counter.AnalyzePrompt(prompt)
+		if err != nil {
+			addAnalysisWarning(result, "prompt", prompt.Name, err)
+			continue
+		}
+		result.Prompts = append(result.Prompts, prompt)
+		result.PromptStats = append(result.PromptStats, promptStats)
+		result.TotalPromptTokens.Add(promptStats)
+	}
+	return nil
+}
+
+// analyzeResources lists and analyzes all resources from the server.
+// Fills in the resource definitions, per-resource stats and accumulated
+// totals of result.
+// Uses the client's paginating iterator to ensure all resources are retrieved.
+// Callers should check server capabilities before calling this function.
+// Returns the error of a failed listing; components that fail to analyze are
+// recorded in result.Warnings instead.
+func analyzeResources(ctx context.Context, client *mcpclient.Client, counter *TokenCounter, result *ServerResult) error {
+	result.TotalResourceTokens = ResourceTokens{Name: TotalLabel}
+
+	for resource, err := range client.Resources(ctx, nil) {
+		if err != nil {
+			return err
+		}
+		resourceStats, err := counter.AnalyzeResource(resource)
+		if err != nil {
+			addAnalysisWarning(result, "resource", resource.Name, err)
+			continue
+		}
+		result.Resources = append(result.Resources, resource)
+		result.ResourceStats = append(result.ResourceStats, resourceStats)
+		result.TotalResourceTokens.Add(resourceStats)
+	}
+	return nil
+}
+
+// analyzeResourceTemplates lists and analyzes all resource templates from
+// the server. Their stats follow the resources' in result.ResourceStats and
+// are added to the same totals, so it must run after analyzeResources.
+// Uses the client's paginating iterator to ensure all templates are retrieved.
+// Callers should check server capabilities before calling this function.
+// Returns the error of a failed listing; components that fail to analyze are
+// recorded in result.Warnings instead.
+func analyzeResourceTemplates(ctx context.Context, client *mcpclient.Client, counter *TokenCounter, result *ServerResult) error {
+	for template, err := range client.ResourceTemplates(ctx, nil) {
+		if err != nil {
+			return err
+		}
+		templateStats, err := counter.AnalyzeResourceTemplate(template)
+		if err != nil {
+			addAnalysisWarning(result, "resource template", template.Name, err)
+			continue
+		}
+		result.ResourceTemplates = append(result.ResourceTemplates, template)
+		result.ResourceStats = append(result.ResourceStats, templateStats)
+		result.TotalResourceTokens.Add(templateStats)
+	}
+	return nil
+}
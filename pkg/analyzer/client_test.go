@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/mcpclient"
+)
+
+func TestAnalyzeServer(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "in-process"}, &mcp.ServerOptions{Instructions: "Query the inventory database."})
+	mcp.AddTool(server, &mcp.Tool{Name: "query", Description: "Run a read-only SQL query"},
+		func(context.Context, *mcp.CallToolRequest, struct {
+			SQL string `json:"sql"`
+		}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	server.AddPrompt(&mcp.Prompt{Name: "summarize", Description: "Summarize a table"}, nil)
+
+	result, err := AnalyzeServer(context.Background(), server, counter)
+	if err != nil {
+		t.Fatalf("failed to analyze server: %v", err)
+	}
+	if result.Error != nil {
+		t.Fatalf("unexpected result error: %v", result.Error)
+	}
+
+	if result.Name != "in-process" {
+		t.Errorf("expected name in-process, got %q", result.Name)
+	}
+	if result.InstructionTokens <= 0 {
+		t.Errorf("expected positive instruction tokens, got %d", result.InstructionTokens)
+	}
+
+	tool, ok := result.Tool("query")
+	if !ok {
+		t.Fatal("expected tool query in results")
+	}
+	if tool.SchemaTokens <= 0 {
+		t.Errorf("expected positive schema tokens, got %d", tool.SchemaTokens)
+	}
+	if len(result.PromptStats) != 1 {
+		t.Errorf("expected 1 prompt, got %d", len(result.PromptStats))
+	}
+	if len(result.ResourceStats) != 0 {
+		t.Errorf("expected no resources, got %d", len(result.ResourceStats))
+	}
+
+	if result.Instructions != "Query the inventory database." {
+		t.Errorf("expected the server instructions, got %q", result.Instructions)
+	}
+	if len(result.Tools) != 1 || result.Tools[0].Description != "Run a read-only SQL query" {
+		t.Errorf("expected the definition of tool query, got %v", result.Tools)
+	}
+	if len(result.Prompts) != 1 || result.Prompts[0].Name != "summarize" {
+		t.Errorf("expected the definition of prompt summarize, got %v", result.Prompts)
+	}
+
+	if result.EffectiveTokens() != result.TotalTokens() {
+		t.Errorf("expected unfiltered effective footprint %d, got %d", result.TotalTokens(), result.EffectiveTokens())
+	}
+}
+
+func TestAnalyzeServer_ListingError(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	// Serve one tool per page and fail every page after the first.
+	server := mcp.NewServer(&mcp.Implementation{Name: "flaky"}, &mcp.ServerOptions{PageSize: 1})
+	for _, name := range []string{"first", "second"} {
+		mcp.AddTool(server, &mcp.Tool{Name: name}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	}
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if params, ok := req.GetParams().(*mcp.ListToolsParams); ok && params.Cursor != "" {
+				return nil, errors.New("page unavailable")
+			}
+			return next(ctx, method, req)
+		}
+	})
+
+	result, err := AnalyzeServer(context.Background(), server, counter)
+	if err != nil {
+		t.Fatalf("failed to analyze server: %v", err)
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "failed to list tools") {
+		t.Errorf("expected a truncated tool listing to fail the result, got error %v", result.Error)
+	}
+}
+
+func TestAnalyzeServer_ListingNotImplemented(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	// A server that advertises resources but rejects listing templates.
+	server := mcp.NewServer(&mcp.Implementation{Name: "partial"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "query", Description: "Run a query"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	})
+	server.AddResource(&mcp.Resource{Name: "readme", URI: "file:///README.md"}, func(context.Context, *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return nil, nil
+	})
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "resources/templates/list" {
+				return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "Method not found"}
+			}
+			return next(ctx, method, req)
+		}
+	})
+
+	result, err := AnalyzeServer(context.Background(), server, counter)
+	if err != nil {
+		t.Fatalf("failed to analyze server: %v", err)
+	}
+	if result.Error != nil {
+		t.Fatalf("expected an unimplemented listing not to fail the result, got error %v", result.Error)
+	}
+	if len(result.ToolStats) != 1 || result.TotalToolTokens.TotalTokens == 0 {
+		t.Errorf("expected the tool to be analyzed, got %+v", result.ToolStats)
+	}
+	if len(result.Resources) != 1 {
+		t.Errorf("expected the resource to be analyzed, got %d resources", len(result.Resources))
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "does not implement listing resource templates") {
+		t.Errorf("expected a warning about the template listing, got %q", result.Warnings)
+	}
+}
+
+func TestAnalyzeClient_RequestTimeout(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	// A server that stops responding once asked for its tools.
+	server := mcp.NewServer(&mcp.Implementation{Name: "stalled"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "query"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	})
+	release := make(chan struct{})
+	defer close(release)
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "tools/list" {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-release:
+				}
+			}
+			return next(ctx, method, req)
+		}
+	})
+
+	ctx := context.Background()
+	client, err := mcpclient.NewInMemoryClient(ctx, server, &mcpclient.ClientOptions{RequestTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	result := AnalyzeClient(ctx, client, counter)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "listing tools timed out after 100ms") {
+		t.Errorf("expected the tool listing to time out, got error %v", result.Error)
+	}
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Kinds of collisions between the tools of different servers.
+const (
+	// CollisionExact groups tools with the same name.
+	CollisionExact = "exact"
+	// CollisionNearDuplicate groups tools whose names differ only in case
+	// and separators, like search_docs and searchDocs.
+	CollisionNearDuplicate = "near-duplicate"
+	// CollisionNamespaced groups tools whose names collide once a client
+	// namespaces them with the server name (see NamespacedToolName).
+	CollisionNamespaced = "namespaced"
+	// CollisionDescription groups tools with the same description.
+	CollisionDescription = "description"
+)
+
+// maxToolNameLength is the longest tool name model APIs accept; clients
+// truncate namespaced names to it.
+const maxToolNameLength = 64
+
+// ToolRef identifies a tool of a server in a Collision.
+type ToolRef struct {
+	Server string
+	Tool   string
+	Tokens int // Tokens the collision is about: the tool's total, or its description's for CollisionDescription
+}
+
+// Collision is a group of tools on different servers that models may
+// confuse, or that clients may drop in favor of one another.
+type Collision struct {
+	Kind  string    // One of the Collision constants
+	Key   string    // Name, normalized name, namespaced name or description the tools share
+	Tools []ToolRef // Sorted by server and tool name
+
+	// RedundantTokens is what the group costs beyond its largest tool:
+	// the tokens saved by keeping a single one of them.
+	RedundantTokens int
+}
+
+// Collisions returns the groups of tools that collide across the servers
+// analyzed successfully, sorted by redundant tokens. Only tools in the
+// effective footprint are considered, since the others are never sent to
+// the model.
+func (r *Report) Collisions() []Collision {
+	groups := make(map[string]map[string][]ToolRef) // Collision kind to key to tools
+	add := func(kind, key string, ref ToolRef) {
+		if groups[kind] == nil {
+			groups[kind] = make(map[string][]ToolRef)
+		}
+		groups[kind][key] = append(groups[kind][key], ref)
+	}
+
+	for _, s := range r.Servers {
+		if s.Error != nil || s.Disabled {
+			continue
+		}
+		for i, stats := range s.ToolStats {
+			if s.ExcludedTools[stats.Name] {
+				continue
+			}
+			ref := ToolRef{Server: s.Name, Tool: stats.Name, Tokens: stats.TotalTokens}
+			add(CollisionExact, stats.Name, ref)
+			add(CollisionNearDuplicate, normalizeToolName(stats.Name), ref)
+			add(CollisionNamespaced, NamespacedToolName(s.Name, stats.Name), ref)
+
+			if i < len(s.Tools) {
+				if desc := strings.TrimSpace(s.Tools[i].Description); desc != "" {
+					add(CollisionDescription, desc, ToolRef{Server: s.Name, Tool: stats.Name, Tokens: stats.DescTokens})
+				}
+			}
+		}
+	}
+
+	var collisions []Collision
+	for kind, byKey := range groups {
+		for key, refs := range byKey {
+			if !collides(kind, refs) {
+				continue
+			}
+			slices.SortFunc(refs, func(a, b ToolRef) int {
+				return cmp.Or(strings.Compare(a.Server, b.Server), strings.Compare(a.Tool, b.Tool))
+			})
+
+			var total, largest int
+			for _, ref := range refs {
+				total += ref.Tokens
+				largest = max(largest, ref.Tokens)
+			}
+			collisions = append(collisions, Collision{Kind: kind, Key: key, Tools: refs, RedundantTokens: total - largest})
+		}
+	}
+
+	kindOrder := []string{CollisionExact, CollisionNearDuplicate, CollisionNamespaced, CollisionDescription}
+	slices.SortFunc(collisions, func(a, b Collision) int {
+		return cmp.Or(
+			cmp.Compare(b.RedundantTokens, a.RedundantTokens),
+			cmp.Compare(slices.Index(kindOrder, a.Kind), slices.Index(kindOrder, b.Kind)),
+			strings.Compare(a.Key, b.Key),
+		)
+	})
+	return collisions
+}
+
+// collides reports whether the tools grouped under a key of kind collide:
+// they must span several servers, and near-duplicate and namespaced groups
+// must hold tools with different names, since identical names are exact
+// collisions already.
+func collides(kind string, refs []ToolRef) bool {
+	servers := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, ref := range refs {
+		servers[ref.Server] = true
+		names[ref.Tool] = true
+	}
+	if len(servers) < 2 {
+		return false
+	}
+	switch kind {
+	case CollisionNearDuplicate, CollisionNamespaced:
+		return len(names) > 1
+	default:
+		return true
+	}
+}
+
+// normalizeToolName returns name in lower case without separators, so that
+// names differing only in style compare equal.
+func normalizeToolName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// NamespacedToolName returns the name a client that namespaces tools with
+// their server's name gives a tool: server and tool joined with an
+// underscore, characters model APIs reject in tool names replaced with
+// underscores, and truncated to 64 characters.
+func NamespacedToolName(server, tool string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, server+"_"+tool)
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// testTool is a tool with its total tokens; its description costs a tenth
+// of them.
+type testTool struct {
+	name, desc string
+	tokens     int
+}
+
+// serverWithTools returns the result of a server with tools.
+func serverWithTools(server string, tools ...testTool) *ServerResult {
+	r := &ServerResult{Name: server}
+	for _, tool := range tools {
+		r.Tools = append(r.Tools, &mcp.Tool{Name: tool.name, Description: tool.desc})
+		r.ToolStats = append(r.ToolStats, ToolTokens{Name: tool.name, DescTokens: tool.tokens / 10, TotalTokens: tool.tokens})
+	}
+	return r
+}
+
+func TestReport_Collisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []*ServerResult
+		want    []string // Kind:key:redundant tokens of each collision, in order
+	}{
+		{
+			name: "no collisions",
+			servers: []*ServerResult{
+				serverWithTools("github", testTool{"create_issue", "Create an issue", 100}),
+				serverWithTools("docs", testTool{"search", "Search the docs", 80}),
+			},
+		},
+		{
+			name: "exact",
+			servers: []*ServerResult{
+				serverWithTools("github", testTool{"search", "Search GitHub", 100}),
+				serverWithTools("docs", testTool{"search", "Search the docs", 80}),
+			},
+			want: []string{"exact:search:80"},
+		},
+		{
+			name: "near-duplicate",
+			servers: []*ServerResult{
+				serverWithTools("jira", testTool{"create_issue", "Create a Jira issue", 120}),
+				serverWithTools("linear", testTool{"createIssue", "Create a Linear issue", 90}),
+			},
+			want: []string{"near-duplicate:createissue:90"},
+		},
+		{
+			name: "namespaced",
+			servers: []*ServerResult{
+				serverWithTools("git", testTool{"hub_push", "Push to the hub", 50}),
+				serverWithTools("git.hub", testTool{"push", "Push a branch", 60}),
+			},
+			want: []string{"namespaced:git_hub_push:50"},
+		},
+		{
+			name: "description",
+			servers: []*ServerResult{
+				serverWithTools("prod", testTool{"query", "Run a SQL query", 200}),
+				serverWithTools("staging", testTool{"run_query", " Run a SQL query\n", 150}),
+			},
+			want: []string{"description:Run a SQL query:15"},
+		},
+		{
+			name: "same server",
+			servers: []*ServerResult{
+				serverWithTools("db", testTool{"query", "Run a query", 100}, testTool{"Query", "Run a query", 100}),
+			},
+		},
+		{
+			name: "sorted by redundant tokens",
+			servers: []*ServerResult{
+				serverWithTools("a", testTool{"search", "Search A", 100}, testTool{"fetch", "Fetch a page", 500}),
+				serverWithTools("b", testTool{"search", "Search B", 100}, testTool{"fetch", "Fetch B", 400}),
+			},
+			want: []string{"exact:fetch:400", "exact:search:100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &Report{Servers: tt.servers}
+			var got []string
+			for _, c := range report.Collisions() {
+				got = append(got, c.Kind+":"+c.Key+":"+strconv.Itoa(c.RedundantTokens))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("expected collisions %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReport_Collisions_Effective(t *testing.T) {
+	github := serverWithTools("github", testTool{"search", "Search GitHub", 100})
+	docs := serverWithTools("docs", testTool{"search", "Search the docs", 80})
+	failed := serverWithTools("failed", testTool{"search", "Search", 10})
+	failed.Error = errors.New("connection refused")
+
+	report := &Report{Servers: []*ServerResult{github, docs, failed}}
+	if c := report.Collisions(); len(c) != 1 || len(c[0].Tools) != 2 {
+		t.Fatalf("expected one collision without the failed server, got %+v", c)
+	}
+
+	docs.ExcludedTools = map[string]bool{"search": true}
+	if c := report.Collisions(); len(c) != 0 {
+		t.Errorf("expected excluded tools not to collide, got %+v", c)
+	}
+
+	docs.ExcludedTools = nil
+	docs.Disabled = true
+	if c := report.Collisions(); len(c) != 0 {
+		t.Errorf("expected tools of disabled servers not to collide, got %+v", c)
+	}
+}
+
+func TestNamespacedToolName(t *testing.T) {
+	tests := []struct {
+		server, tool string
+		want         string
+	}{
+		{server: "github", tool: "create_issue", want: "github_create_issue"},
+		{server: "my server", tool: "get.page", want: "my_server_get_page"},
+		{server: "docs", tool: "tool-name", want: "docs_tool-name"},
+		{server: "docs", tool: strings.Repeat("x", 80), want: "docs_" + strings.Repeat("x", 59)},
+	}
+	for _, tt := range tests {
+		if got := NamespacedToolName(tt.server, tt.tool); got != tt.want {
+			t.Errorf("NamespacedToolName(%q, %q) = %q, want %q", tt.server, tt.tool, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"cmp"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a single token of an encoded text.
+type Token struct {
+	ID   int
+	Text string // Bytes the token stands for; may be part of a multi-byte character
+}
+
+// Tokens encodes text and returns its tokens in order. The texts of the
+// tokens concatenate to text.
+// It is safe for concurrent use.
+func (c *TokenCounter) Tokens(text string) []Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := c.enc.Encode(text, nil, nil)
+	tokens := make([]Token, len(ids))
+	for i, id := range ids {
+		tokens[i] = Token{ID: id, Text: c.enc.Decode([]int{id})}
+	}
+	return tokens
+}
+
+// WordCost is the number of tokens spent on a word.
+type WordCost struct {
+	Word        string
+	Tokens      int // Tokens of the costliest occurrence; tokens spanning several words count toward each
+	Occurrences int
+}
+
+// ExpensiveWords returns up to n words of the tokenized texts that cost more
+// than one token, costliest first. Texts are split into words at whitespace
+// and at ASCII punctuation other than '_' and '-', so identifiers and runs of
+// rare characters are single words, while JSON syntax separates the names in
+// a schema.
+func ExpensiveWords(n int, texts ...[]Token) []WordCost {
+	costs := make(map[string]*WordCost)
+	for _, tokens := range texts {
+		for _, w := range wordCosts(tokens) {
+			c, ok := costs[w.Word]
+			if !ok {
+				c = &WordCost{Word: w.Word}
+				costs[w.Word] = c
+			}
+			c.Tokens = max(c.Tokens, w.Tokens)
+			c.Occurrences++
+		}
+	}
+
+	var words []WordCost
+	for _, c := range costs {
+		if c.Tokens > 1 {
+			words = append(words, *c)
+		}
+	}
+	slices.SortFunc(words, func(a, b WordCost) int {
+		return cmp.Or(
+			cmp.Compare(b.Tokens, a.Tokens),
+			cmp.Compare(b.Occurrences, a.Occurrences),
+			cmp.Compare(a.Word, b.Word),
+		)
+	})
+	if len(words) > n {
+		words = words[:n]
+	}
+	return words
+}
+
+// wordCosts splits the text of tokens into words and returns each occurrence
+// with the number of tokens overlapping it.
+func wordCosts(tokens []Token) []WordCost {
+	var text []byte
+	ends := make([]int, len(tokens)) // End offset of each token in text
+	for i, t := range tokens {
+		text = append(text, t.Text...)
+		ends[i] = len(text)
+	}
+
+	var (
+		words []WordCost
+		tok   int // First token that may overlap the current word
+	)
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		if !isWordRune(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		for i < len(text) {
+			r, size := utf8.DecodeRune(text[i:])
+			if !isWordRune(r) {
+				break
+			}
+			i += size
+		}
+
+		for tok < len(tokens) && ends[tok] <= start {
+			tok++
+		}
+		count := 0
+		for j := tok; j < len(tokens) && (j == 0 || ends[j-1] < i); j++ {
+			count++
+		}
+		words = append(words, WordCost{Word: string(text[start:i]), Tokens: count, Occurrences: 1})
+	}
+	return words
+}
+
+// isWordRune reports whether r is part of a word rather than a separator.
+func isWordRune(r rune) bool {
+	if r == '_' || r == '-' {
+		return true
+	}
+	return !unicode.IsSpace(r) && (r > unicode.MaxASCII || !unicode.IsPunct(r) && !unicode.IsSymbol(r))
+}
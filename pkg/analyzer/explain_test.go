@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenCounter_Tokens(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	tests := []string{
+		"",
+		"hello world",
+		"get_user_by_id fetches a user",
+		"日本語のテキスト 🎉",
+		"line one\nline two\n",
+	}
+
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			tokens := counter.Tokens(text)
+			if len(tokens) != counter.CountTokens(text) {
+				t.Errorf("expected %d tokens, got %d", counter.CountTokens(text), len(tokens))
+			}
+
+			var b strings.Builder
+			for _, tok := range tokens {
+				b.WriteString(tok.Text)
+			}
+			if b.String() != text {
+				t.Errorf("token texts concatenate to %q, want %q", b.String(), text)
+			}
+		})
+	}
+}
+
+func TestExpensiveWords(t *testing.T) {
+	tokens := func(texts ...string) []Token {
+		var ts []Token
+		for i, text := range texts {
+			ts = append(ts, Token{ID: i, Text: text})
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name  string
+		n     int
+		texts [][]Token
+		want  []WordCost
+	}{
+		{
+			name:  "single-token words are left out",
+			n:     10,
+			texts: [][]Token{tokens("the", " quick", " fox")},
+			want:  nil,
+		},
+		{
+			name:  "costliest first",
+			n:     10,
+			texts: [][]Token{tokens("get", "_user", " a", " list", "_all", "_users")},
+			want: []WordCost{
+				{Word: "list_all_users", Tokens: 3, Occurrences: 1},
+				{Word: "get_user", Tokens: 2, Occurrences: 1},
+			},
+		},
+		{
+			name:  "tokens spanning words count toward each",
+			n:     10,
+			texts: [][]Token{tokens("ab", "c d", "ef")},
+			want: []WordCost{
+				{Word: "abc", Tokens: 2, Occurrences: 1},
+				{Word: "def", Tokens: 2, Occurrences: 1},
+			},
+		},
+		{
+			name: "occurrences across texts",
+			n:    10,
+			texts: [][]Token{
+				tokens("foo", "_bar", " x"),
+				tokens("foo", "_bar"),
+				tokens("ba", "z", "_q"),
+			},
+			want: []WordCost{
+				{Word: "baz_q", Tokens: 3, Occurrences: 1},
+				{Word: "foo_bar", Tokens: 2, Occurrences: 2},
+			},
+		},
+		{
+			name:  "json syntax separates words",
+			n:     10,
+			texts: [][]Token{tokens(`{"`, "user", "_id", `":{"`, "type", `":"`, "string", `"}}`)},
+			want:  []WordCost{{Word: "user_id", Tokens: 2, Occurrences: 1}},
+		},
+		{
+			name:  "limited to n",
+			n:     1,
+			texts: [][]Token{tokens("a", "b", " c", "d", "e")},
+			want:  []WordCost{{Word: "cde", Tokens: 3, Occurrences: 1}},
+		},
+		{
+			name:  "multi-byte characters split across tokens",
+			n:     10,
+			texts: [][]Token{tokens("\xf0\x9f", "\x8e\x89", "\n", "ok")},
+			want:  []WordCost{{Word: "🎉", Tokens: 2, Occurrences: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExpensiveWords(tt.n, tt.texts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpensiveWords() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
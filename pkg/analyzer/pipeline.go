@@ -0,0 +1,288 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+	"github.com/tjhop/mcp-token-analyzer/pkg/mcpclient"
+)
+
+const (
+	// defaultConcurrency is the number of servers analyzed at once unless
+	// Options.Concurrency is set.
+	defaultConcurrency = 10
+
+	// UnknownServerName names results of ad-hoc servers that did not
+	// report a name.
+	UnknownServerName = "<unknown>"
+)
+
+// Options configures an Analyzer.
+type Options struct {
+	// Counter counts tokens. If nil, a counter for Model is created.
+	Counter *TokenCounter
+	// Model selects the tokenizer when Counter is nil, as accepted by
+	// NewTokenCounter.
+	Model string
+
+	// Concurrency limits the number of servers analyzed at once. Zero
+	// uses a default of 10.
+	Concurrency int
+
+	// ToolFilter reports whether a tool of the named server counts toward
+	// the effective footprint, on top of the server's client config
+	// (disabled servers and tool allow/deny lists). If nil, every tool the
+	// client config enables is counted.
+	ToolFilter func(server, tool string) bool
+
+	// Client configures connections to the servers: transport fallback,
+	// timeouts and retries. Per-server fields (Name, Env, Headers, Dir) are
+	// taken from each server's config, and Stderr from ServerStderr.
+	Client mcpclient.ClientOptions
+
+	// ServerStderr, if set, is called before a stdio server is started and
+	// returns the writer its stderr is copied to. The writer is closed
+	// once the server's session is closed. name is the configured server
+	// name, which is empty for ad-hoc servers.
+	ServerStderr func(name string, srv *config.ServerConfig) (io.WriteCloser, error)
+
+	// Progress, if set, is called when the analysis of a server starts and
+	// when it finishes. Calls are serialized.
+	Progress func(ProgressEvent)
+}
+
+// ProgressEvent reports the progress of Analyzer.Analyze.
+type ProgressEvent struct {
+	Server    string        // Configured server name
+	Result    *ServerResult // Set once the server has been analyzed
+	Completed int           // Servers analyzed so far
+	Total     int           // Servers to analyze
+}
+
+// Analyzer connects to MCP servers and analyzes their token footprint. It is
+// safe for concurrent use.
+type Analyzer struct {
+	opts    Options
+	counter *TokenCounter
+}
+
+// New creates an Analyzer. Pass nil for opts to use the defaults.
+func New(opts *Options) (*Analyzer, error) {
+	a := &Analyzer{}
+	if opts != nil {
+		a.opts = *opts
+	}
+
+	a.counter = a.opts.Counter
+	if a.counter == nil {
+		var err error
+		a.counter, err = NewTokenCounter(a.opts.Model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize token counter: %w", err)
+		}
+	}
+
+	return a, nil
+}
+
+// Counter returns the TokenCounter used by the Analyzer.
+func (a *Analyzer) Counter() *TokenCounter {
+	return a.counter
+}
+
+// Analyze connects to all servers in parallel and returns their results,
+// sorted by name. Failures are reported per server in the results. The
+// servers map and its ServerConfig values are treated as read-only.
+func (a *Analyzer) Analyze(ctx context.Context, servers map[string]*config.ServerConfig) *Report {
+	var (
+		report    = &Report{}
+		mu        sync.Mutex
+		completed int
+	)
+	progress := func(name string, result *ServerResult) {
+		if a.opts.Progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if result != nil {
+			completed++
+		}
+		a.opts.Progress(ProgressEvent{Server: name, Result: result, Completed: completed, Total: len(servers)})
+	}
+
+	concurrency := a.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	// Using errgroup.Group over sync.WaitGroup for built in concurrency
+	// limiter.
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+
+	for name, srv := range servers {
+		g.Go(func() error {
+			progress(name, nil)
+			result := a.AnalyzeServerConfig(ctx, name, srv)
+
+			mu.Lock()
+			report.Servers = append(report.Servers, result)
+			mu.Unlock()
+
+			progress(name, result)
+			// Don't return error - we want to continue analyzing other servers
+			return nil
+		})
+	}
+
+	// Errors are captured in individual results, not returned via errgroup.
+	// Each goroutine returns nil to allow all servers to be processed.
+	_ = g.Wait()
+
+	report.sort()
+
+	return report
+}
+
+// AnalyzeServerConfig connects to a single server and analyzes it.
+// The configured name takes precedence over the server-reported name from
+// the init response. For ad-hoc servers (empty name), the server-reported
+// name is used as fallback.
+func (a *Analyzer) AnalyzeServerConfig(ctx context.Context, name string, srv *config.ServerConfig) *ServerResult {
+	s, result := a.connect(ctx, name, srv, nil, nil)
+	if result != nil {
+		return result
+	}
+	defer s.close()
+
+	return a.analyzeSession(ctx, s)
+}
+
+// session is an open connection to a configured server.
+type session struct {
+	name            string
+	srv             *config.ServerConfig
+	client          *mcpclient.Client
+	stderr          io.Closer
+	connectDuration time.Duration
+
+	// ended is closed, if not nil, once the session has ended with endErr.
+	ended  chan struct{}
+	endErr error
+}
+
+// close closes the client and then the server's stderr writer, so that the
+// writer outlives the process.
+func (s *session) close() {
+	_ = s.client.Close()
+	if s.stderr != nil {
+		_ = s.stderr.Close()
+	}
+}
+
+// connect opens a session to a single server, passing listChanged and
+// rawResults (if not nil) on as mcpclient.ClientOptions.ListChanged and
+// RawResults. If the server cannot be connected to, the failed result is
+// returned instead.
+func (a *Analyzer) connect(ctx context.Context, name string, srv *config.ServerConfig, listChanged func(), rawResults func(string, json.RawMessage)) (*session, *ServerResult) {
+	s := &session{name: name, srv: srv}
+
+	opts := a.opts.Client
+	opts.ListChanged = listChanged
+	opts.RawResults = rawResults
+	if a.opts.ServerStderr != nil && srv.Type == config.TransportStdio {
+		stderr, err := a.opts.ServerStderr(name, srv)
+		if err != nil {
+			return nil, &ServerResult{Name: resolveServerName(name, nil), SourceFile: srv.Source.File, Disabled: srv.Disabled, Error: err}
+		}
+		s.stderr = stderr
+		opts.Stderr = stderr
+	}
+
+	start := time.Now()
+	client, err := mcpclient.NewClientFromConfig(ctx, srv, &opts)
+	s.connectDuration = time.Since(start)
+	if err != nil {
+		if s.stderr != nil {
+			_ = s.stderr.Close()
+		}
+		result := &ServerResult{
+			Name:            resolveServerName(name, nil),
+			SourceFile:      srv.Source.File,
+			Disabled:        srv.Disabled,
+			Error:           err,
+			ConnectDuration: s.connectDuration,
+		}
+		var retryErr *mcpclient.RetryError
+		if errors.As(err, &retryErr) {
+			result.Retries = retryErr.Attempts - 1
+		}
+		return nil, result
+	}
+	s.client = client
+
+	return s, nil
+}
+
+// resolveName resolves the display name of the session's server from the
+// configured name and whatever the server reported during initialization.
+func (s *session) resolveName() string {
+	var serverInfo *mcp.Implementation
+	if initResp := s.client.InitializeResult(); initResp != nil {
+		serverInfo = initResp.ServerInfo
+	}
+	return resolveServerName(s.name, serverInfo)
+}
+
+// analyzeSession analyzes the server behind an open session.
+func (a *Analyzer) analyzeSession(ctx context.Context, s *session) *ServerResult {
+	result := AnalyzeClient(ctx, s.client, a.counter)
+	result.Name = s.resolveName()
+	result.SourceFile = s.srv.Source.File
+	result.ConnectDuration = s.connectDuration
+	applyToolFilter(result, s.srv, a.opts.ToolFilter)
+
+	return result
+}
+
+// resolveServerName determines the display name for a server, preferring
+// the configured name over the server-reported name.
+func resolveServerName(configuredName string, serverInfo *mcp.Implementation) string {
+	switch {
+	case configuredName != "":
+		return configuredName
+	case serverInfo != nil && serverInfo.Name != "":
+		return serverInfo.Name
+	default:
+		return UnknownServerName
+	}
+}
+
+// applyToolFilter computes the effective footprint of a server result from
+// its full per-tool stats, honoring the server's client config and filter
+// (if not nil).
+func applyToolFilter(r *ServerResult, srv *config.ServerConfig, filter func(server, tool string) bool) {
+	r.Disabled = srv.Disabled
+	r.EffectiveToolTokens = ToolTokens{Name: TotalLabel}
+
+	for _, t := range r.ToolStats {
+		if srv.ToolEnabled(t.Name) && (filter == nil || filter(r.Name, t.Name)) {
+			r.EffectiveToolTokens.Add(t)
+			continue
+		}
+		if r.ExcludedTools == nil {
+			r.ExcludedTools = make(map[string]bool)
+		}
+		r.ExcludedTools[t.Name] = true
+	}
+}
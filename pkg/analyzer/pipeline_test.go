@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+func TestResolveServerName(t *testing.T) {
+	tests := []struct {
+		name           string
+		configuredName string
+		serverInfo     *mcp.Implementation
+		want           string
+	}{
+		{
+			name:           "configured_name_only",
+			configuredName: "my-server",
+			serverInfo:     nil,
+			want:           "my-server",
+		},
+		{
+			name:           "configured_name_takes_precedence",
+			configuredName: "configured",
+			serverInfo:     &mcp.Implementation{Name: "server-reported"},
+			want:           "configured",
+		},
+		{
+			name:           "serverinfo_name_when_no_configured",
+			configuredName: "",
+			serverInfo:     &mcp.Implementation{Name: "server-reported"},
+			want:           "server-reported",
+		},
+		{
+			name:           "serverinfo_with_version",
+			configuredName: "",
+			serverInfo:     &mcp.Implementation{Name: "my-mcp-server", Version: "1.0.0"},
+			want:           "my-mcp-server",
+		},
+		{
+			name:           "both_empty_returns_unknown",
+			configuredName: "",
+			serverInfo:     nil,
+			want:           UnknownServerName,
+		},
+		{
+			name:           "serverinfo_with_empty_name_falls_through",
+			configuredName: "",
+			serverInfo:     &mcp.Implementation{Name: "", Version: "1.0.0"},
+			want:           UnknownServerName,
+		},
+		{
+			name:           "configured_empty_string_uses_serverinfo",
+			configuredName: "",
+			serverInfo:     &mcp.Implementation{Name: "fallback-name"},
+			want:           "fallback-name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveServerName(tt.configuredName, tt.serverInfo); got != tt.want {
+				t.Errorf("resolveServerName(%q, %v) = %q, want %q", tt.configuredName, tt.serverInfo, got, tt.want)
+			}
+		})
+	}
+}
+
+func newToolFilterTestResult() *ServerResult {
+	return &ServerResult{
+		Name:              "github",
+		InstructionTokens: 10,
+		TotalToolTokens:   ToolTokens{TotalTokens: 600},
+		TotalPromptTokens: PromptTokens{TotalTokens: 5},
+		ToolStats: []ToolTokens{
+			{Name: "search", TotalTokens: 100},
+			{Name: "get_issue", TotalTokens: 200},
+			{Name: "create_issue", TotalTokens: 300},
+		},
+	}
+}
+
+func TestApplyToolFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		srv           config.ServerConfig
+		filter        func(server, tool string) bool
+		wantEffective int
+		wantExcluded  []string
+	}{
+		{
+			name:          "unfiltered",
+			wantEffective: 615,
+		},
+		{
+			name:          "disabled_server",
+			srv:           config.ServerConfig{Disabled: true},
+			wantEffective: 0,
+			wantExcluded:  []string{"search", "get_issue", "create_issue"},
+		},
+		{
+			name:          "config_disabled_tools",
+			srv:           config.ServerConfig{DisabledTools: []string{"create_issue"}},
+			wantEffective: 315,
+			wantExcluded:  []string{"create_issue"},
+		},
+		{
+			name:          "config_and_flag_combined",
+			srv:           config.ServerConfig{EnabledTools: []string{"search", "get_issue"}},
+			filter:        func(_, tool string) bool { return !strings.HasPrefix(tool, "get_") },
+			wantEffective: 115,
+			wantExcluded:  []string{"get_issue", "create_issue"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newToolFilterTestResult()
+			applyToolFilter(r, &tt.srv, tt.filter)
+
+			if got := r.EffectiveTokens(); got != tt.wantEffective {
+				t.Errorf("EffectiveTokens() = %d, want %d", got, tt.wantEffective)
+			}
+			if got := r.TotalTokens(); got != 615 {
+				t.Errorf("TotalTokens() = %d, want full footprint 615", got)
+			}
+			if len(r.ExcludedTools) != len(tt.wantExcluded) {
+				t.Errorf("expected excluded tools %v, got %v", tt.wantExcluded, r.ExcludedTools)
+			}
+			for _, name := range tt.wantExcluded {
+				if !r.ExcludedTools[name] {
+					t.Errorf("expected tool %q to be excluded", name)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	counter, err := NewTokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "remote"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "search", Description: "Search the docs"},
+		func(context.Context, *mcp.CallToolRequest, struct{ Query string }) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "delete", Description: "Delete a page"},
+		func(context.Context, *mcp.CallToolRequest, struct{ Page string }) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	var (
+		mu     sync.Mutex
+		events []ProgressEvent
+	)
+	a, err := New(&Options{
+		Counter:    counter,
+		ToolFilter: func(_, tool string) bool { return tool != "delete" },
+		Progress: func(e ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report := a.Analyze(context.Background(), map[string]*config.ServerConfig{
+		"docs":   {Type: config.TransportHTTP, URL: ts.URL},
+		"broken": {Type: "carrier-pigeon"},
+	})
+
+	if len(report.Servers) != 2 || report.Servers[0].Name != "broken" || report.Servers[1].Name != "docs" {
+		t.Fatalf("expected results for broken and docs sorted by name, got %+v", report.Servers)
+	}
+	if report.Servers[0].Error == nil {
+		t.Error("expected an error for the unsupported transport")
+	}
+	if report.Failures() != 1 {
+		t.Errorf("expected 1 failure, got %d", report.Failures())
+	}
+
+	docs := report.Servers[1]
+	if docs.Error != nil {
+		t.Fatalf("unexpected error: %v", docs.Error)
+	}
+	if len(docs.ToolStats) != 2 || !docs.ExcludedTools["delete"] {
+		t.Errorf("expected 2 tools with delete excluded, got %v excluding %v", docs.ToolStats, docs.ExcludedTools)
+	}
+	if docs.EffectiveTokens() >= docs.TotalTokens() {
+		t.Errorf("expected the effective footprint %d below the full footprint %d", docs.EffectiveTokens(), docs.TotalTokens())
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected start and finish events for both servers, got %d", len(events))
+	}
+	if last := events[len(events)-1]; last.Completed != 2 || last.Total != 2 || last.Result == nil {
+		t.Errorf("unexpected final progress event: %+v", last)
+	}
+}
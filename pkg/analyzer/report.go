@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Report holds the results of analyzing a set of MCP servers.
+type Report struct {
+	Servers []*ServerResult
+}
+
+// TotalTokens returns the combined token count of every server analyzed
+// successfully.
+func (r *Report) TotalTokens() int {
+	var total int
+	for _, s := range r.Servers {
+		if s.Error == nil {
+			total += s.TotalTokens()
+		}
+	}
+	return total
+}
+
+// EffectiveTokens returns the combined effective token count of every server
+// analyzed successfully.
+func (r *Report) EffectiveTokens() int {
+	var total int
+	for _, s := range r.Servers {
+		if s.Error == nil {
+			total += s.EffectiveTokens()
+		}
+	}
+	return total
+}
+
+// Failures returns the number of servers that failed analysis. Disabled
+// servers are not counted: clients don't start them, so a disabled server
+// that fails does not affect the effective footprint.
+func (r *Report) Failures() int {
+	var n int
+	for _, s := range r.Servers {
+		if s.Error != nil && !s.Disabled {
+			n++
+		}
+	}
+	return n
+}
+
+// Err returns an error summarizing failed servers, or nil if every server
+// was analyzed successfully. The individual errors are in the results.
+func (r *Report) Err() error {
+	if n := r.Failures(); n > 0 {
+		return fmt.Errorf("%d of %d servers failed analysis", n, len(r.Servers))
+	}
+	return nil
+}
+
+// sort sorts the results by name for consistent output. Servers of the
+// same name, such as one defined in several config files, are ordered by
+// the file they came from.
+func (r *Report) sort() {
+	sort.SliceStable(r.Servers, func(i, j int) bool {
+		a, b := r.Servers[i], r.Servers[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.SourceFile < b.SourceFile
+	})
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	report := &Report{
+		Servers: []*ServerResult{
+			{Name: "a", InstructionTokens: 10, TotalToolTokens: ToolTokens{TotalTokens: 90}, EffectiveToolTokens: ToolTokens{TotalTokens: 40}},
+			{Name: "b", InstructionTokens: 5, Disabled: true},
+			{Name: "c", InstructionTokens: 1000, Error: errors.New("connection refused")},
+			{Name: "d", Disabled: true, Error: errors.New("connection refused")},
+		},
+	}
+
+	if got := report.TotalTokens(); got != 105 {
+		t.Errorf("TotalTokens() = %d, want 105", got)
+	}
+	if got := report.EffectiveTokens(); got != 50 {
+		t.Errorf("EffectiveTokens() = %d, want 50", got)
+	}
+	if got := report.Failures(); got != 1 {
+		t.Errorf("Failures() = %d, want 1", got)
+	}
+	if err := report.Err(); err == nil || err.Error() != "1 of 4 servers failed analysis" {
+		t.Errorf("unexpected Err(): %v", err)
+	}
+
+	report.Servers = slices.Delete(report.Servers, 2, 3)
+	if err := report.Err(); err != nil {
+		t.Errorf("expected no error without failures of enabled servers, got %v", err)
+	}
+}
+
+func TestReport_Sort(t *testing.T) {
+	report := &Report{
+		Servers: []*ServerResult{
+			{Name: "github", SourceFile: "/work/.vscode/mcp.json"},
+			{Name: "docs", SourceFile: "/home/user/.cursor/mcp.json"},
+			{Name: "github", SourceFile: "/home/user/.cursor/mcp.json"},
+		},
+	}
+	report.sort()
+
+	var got []string
+	for _, s := range report.Servers {
+		got = append(got, s.Name+" "+s.SourceFile)
+	}
+	want := []string{
+		"docs /home/user/.cursor/mcp.json",
+		"github /home/user/.cursor/mcp.json",
+		"github /work/.vscode/mcp.json",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected servers sorted by name and source file, got %q", got)
+	}
+}
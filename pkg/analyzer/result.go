@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TotalLabel is the name given to the accumulated totals of a ServerResult.
+const TotalLabel = "TOTAL"
+
+// Kinds of server components, for reporting token counts per component.
+const (
+	KindInstructions = "instructions"
+	KindTool         = "tool"
+	KindPrompt       = "prompt"
+	KindResource     = "resource" // Resources and resource templates
+)
+
+// ServerResult holds the analysis results for a single MCP server.
+type ServerResult struct {
+	Name                string
+	SourceFile          string              // Config file the server was defined in; empty for ad-hoc servers
+	ServerInfo          *mcp.Implementation // Name and version the server reported; nil if it could not be connected to
+	Error               error
+	InstructionTokens   int
+	TotalToolTokens     ToolTokens
+	TotalPromptTokens   PromptTokens
+	TotalResourceTokens ResourceTokens
+
+	// Effective footprint: what the client actually sends to the model once
+	// disabled servers and filtered tools are left out.
+	Disabled            bool            // Server is disabled in its client config
+	EffectiveToolTokens ToolTokens      // Totals of the tools not in ExcludedTools
+	ExcludedTools       map[string]bool // Tools filtered out of the effective footprint
+
+	// Warnings describes components that could not be analyzed, and
+	// listings the server does not implement; both are left out of the
+	// totals.
+	Warnings []string
+
+	Retries         int           // Retried connection attempts and list requests
+	ConnectDuration time.Duration // Time taken to connect and initialize, including retries
+
+	// Per-component stats
+	ToolStats     []ToolTokens
+	PromptStats   []PromptTokens
+	ResourceStats []ResourceTokens
+
+	// Definitions the stats were computed from. Tools and Prompts are
+	// index-aligned with ToolStats and PromptStats; ResourceStats holds the
+	// stats of Resources followed by those of ResourceTemplates.
+	Instructions      string
+	Tools             []*mcp.Tool
+	Prompts           []*mcp.Prompt
+	Resources         []*mcp.Resource
+	ResourceTemplates []*mcp.ResourceTemplate
+}
+
+// TotalTokens returns the grand total of all tokens for this server.
+func (r *ServerResult) TotalTokens() int {
+	return r.InstructionTokens + r.TotalToolTokens.TotalTokens + r.TotalPromptTokens.TotalTokens + r.TotalResourceTokens.TotalTokens
+}
+
+// EffectiveTokens returns the total tokens the client actually sends to the
+// model for this server: zero if the server is disabled, otherwise
+// TotalTokens without the excluded tools.
+func (r *ServerResult) EffectiveTokens() int {
+	if r.Disabled {
+		return 0
+	}
+	return r.InstructionTokens + r.EffectiveToolTokens.TotalTokens + r.TotalPromptTokens.TotalTokens + r.TotalResourceTokens.TotalTokens
+}
+
+// IsFiltered reports whether the effective footprint differs from the full
+// one because the server is disabled or tools were excluded.
+func (r *ServerResult) IsFiltered() bool {
+	return r.Disabled || len(r.ExcludedTools) > 0
+}
+
+// Tool returns the stats of the named tool, if the server has it.
+func (r *ServerResult) Tool(name string) (ToolTokens, bool) {
+	for _, t := range r.ToolStats {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return ToolTokens{}, false
+}
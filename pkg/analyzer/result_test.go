@@ -0,0 +1,90 @@
+package analyzer
+
+import "testing"
+
+func TestServerResult_TotalTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ServerResult
+		want   int
+	}{
+		{
+			name:   "empty_result",
+			result: ServerResult{},
+			want:   0,
+		},
+		{
+			name: "only_instructions",
+			result: ServerResult{
+				InstructionTokens: 100,
+			},
+			want: 100,
+		},
+		{
+			name: "only_tools",
+			result: ServerResult{
+				TotalToolTokens: ToolTokens{TotalTokens: 200},
+			},
+			want: 200,
+		},
+		{
+			name: "only_prompts",
+			result: ServerResult{
+				TotalPromptTokens: PromptTokens{TotalTokens: 150},
+			},
+			want: 150,
+		},
+		{
+			name: "only_resources",
+			result: ServerResult{
+				TotalResourceTokens: ResourceTokens{TotalTokens: 75},
+			},
+			want: 75,
+		},
+		{
+			name: "all_components",
+			result: ServerResult{
+				InstructionTokens:   100,
+				TotalToolTokens:     ToolTokens{TotalTokens: 200},
+				TotalPromptTokens:   PromptTokens{TotalTokens: 150},
+				TotalResourceTokens: ResourceTokens{TotalTokens: 75},
+			},
+			want: 525,
+		},
+		{
+			name: "realistic_values",
+			result: ServerResult{
+				Name:                "test-server",
+				InstructionTokens:   50,
+				TotalToolTokens:     ToolTokens{Name: "TOTAL", TotalTokens: 1500},
+				TotalPromptTokens:   PromptTokens{Name: "TOTAL", TotalTokens: 300},
+				TotalResourceTokens: ResourceTokens{Name: "TOTAL", TotalTokens: 250},
+			},
+			want: 2100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.TotalTokens(); got != tt.want {
+				t.Errorf("ServerResult.TotalTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerResult_Tool(t *testing.T) {
+	result := ServerResult{
+		ToolStats: []ToolTokens{
+			{Name: "query", TotalTokens: 120},
+			{Name: "list", TotalTokens: 40},
+		},
+	}
+
+	got, ok := result.Tool("list")
+	if !ok || got.TotalTokens != 40 {
+		t.Errorf("Tool(%q) = %+v, %v; want 40 tokens", "list", got, ok)
+	}
+	if _, ok := result.Tool("missing"); ok {
+		t.Errorf("Tool(%q) found a tool that does not exist", "missing")
+	}
+}
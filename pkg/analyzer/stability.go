@@ -0,0 +1,480 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// Defaults for StabilityOptions.
+const (
+	defaultStabilitySessions = 2
+	defaultStabilitySamples  = 3
+)
+
+// StabilityOptions configures Analyzer.CheckStability.
+type StabilityOptions struct {
+	// Sessions is the number of sessions opened to each server, one after
+	// another. Zero uses a default of 2.
+	Sessions int
+	// Samples is the number of times the components are listed in each
+	// session. Zero uses a default of 3.
+	Samples int
+	// Interval is the delay between listings, which gives content derived
+	// from the current time a chance to change.
+	Interval time.Duration
+}
+
+// StabilityResult holds the outcome of listing a server's components
+// repeatedly.
+//
+// Providers cache prompts by prefix, so a definition that differs between
+// requests invalidates the cache from that definition onward: everything
+// after it in the prompt is processed as uncached input again.
+type StabilityResult struct {
+	Name       string
+	SourceFile string // Config file the server was defined in; empty for ad-hoc servers
+	Error      error
+	Listings   int // Listings compared, across all sessions
+
+	// Components holds every component listed at least once, in the order
+	// of the first listing it appeared in, preceded by the instructions.
+	Components []ComponentStability
+	// ReorderedKinds holds the kinds of components whose list order
+	// differed between listings.
+	ReorderedKinds []string
+
+	TotalTokens        int // Tokens of all components as first listed
+	CacheBustingTokens int // Tokens from the first definition that differed between listings onward
+}
+
+// ComponentStability describes how a component's definition varied between
+// listings.
+type ComponentStability struct {
+	Kind   string // One of the Kind constants
+	Name   string // Empty for the instructions
+	Tokens int    // Tokens of the component as first listed
+
+	Missing         bool // It was absent from some listings
+	ContentChanged  bool // Its content differed between listings
+	KeyOrderChanged bool // Only the order of its object keys differed between listings
+}
+
+// Stable reports whether the component was listed identically every time.
+func (c ComponentStability) Stable() bool {
+	return !c.Missing && !c.ContentChanged && !c.KeyOrderChanged
+}
+
+// Stable reports whether the server listed the same definitions in the same
+// order every time.
+func (r *StabilityResult) Stable() bool {
+	return r.Error == nil && len(r.ReorderedKinds) == 0 && !slices.ContainsFunc(r.Components, func(c ComponentStability) bool {
+		return !c.Stable()
+	})
+}
+
+// listedComponent is a component as listed once.
+type listedComponent struct {
+	kind, name string
+	tokens     int
+	canonical  [sha256.Size]byte // Hash of the definition with object keys sorted
+	raw        [sha256.Size]byte // Hash of the definition as the server encoded it
+}
+
+// key identifies the component across listings.
+func (c listedComponent) key() string {
+	return c.kind + "/" + c.name
+}
+
+// CheckStability lists the components of each server several times, across
+// several sessions, and reports whether their definitions and ordering stay
+// the same. Servers are checked in parallel and the results are sorted by
+// name. Pass nil for opts to use the defaults.
+func (a *Analyzer) CheckStability(ctx context.Context, servers map[string]*config.ServerConfig, opts *StabilityOptions) []*StabilityResult {
+	var o StabilityOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Sessions <= 0 {
+		o.Sessions = defaultStabilitySessions
+	}
+	if o.Samples <= 0 {
+		o.Samples = defaultStabilitySamples
+	}
+
+	concurrency := a.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var (
+		results []*StabilityResult
+		mu      sync.Mutex
+		g       errgroup.Group
+	)
+	g.SetLimit(concurrency)
+	for name, srv := range servers {
+		g.Go(func() error {
+			result := a.checkServerStability(ctx, name, srv, o)
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	slices.SortFunc(results, func(a, b *StabilityResult) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return results
+}
+
+// checkServerStability lists the components of a single server as
+// configured by o and compares the listings.
+func (a *Analyzer) checkServerStability(ctx context.Context, name string, srv *config.ServerConfig, o StabilityOptions) *StabilityResult {
+	result := &StabilityResult{Name: resolveServerName(name, nil), SourceFile: srv.Source.File}
+	rec := &rawRecorder{results: make(map[string][]json.RawMessage)}
+
+	var listings [][]listedComponent
+	for i := range o.Sessions {
+		s, failed := a.connect(ctx, name, srv, nil, rec.record)
+		if failed != nil {
+			result.Error = failed.Error
+			return result
+		}
+		if i == 0 {
+			result.Name = s.resolveName()
+		}
+
+		for range o.Samples {
+			if len(listings) > 0 && !sleep(ctx, o.Interval) {
+				s.close()
+				result.Error = ctx.Err()
+				return result
+			}
+			listing, err := a.listComponents(ctx, s, rec)
+			if err != nil {
+				s.close()
+				result.Error = err
+				return result
+			}
+			listings = append(listings, listing)
+		}
+		s.close()
+	}
+
+	compareListings(result, listings)
+	return result
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// listComponents lists the instructions and all components the session's
+// server advertises, in the order a client would send them to the model.
+// Unlike an analysis, a failed or partial listing is an error: it would
+// look like the definitions changed.
+func (a *Analyzer) listComponents(ctx context.Context, s *session, rec *rawRecorder) ([]listedComponent, error) {
+	initResp := s.client.InitializeResult()
+	if initResp == nil {
+		return nil, errors.New("MCP session not initialized")
+	}
+
+	var listing []listedComponent
+	if initResp.Instructions != "" {
+		sum := sha256.Sum256([]byte(initResp.Instructions))
+		listing = append(listing, listedComponent{
+			kind:      KindInstructions,
+			tokens:    a.counter.CountTokens(initResp.Instructions),
+			canonical: sum,
+			raw:       sum,
+		})
+	}
+
+	caps := initResp.Capabilities
+	var lists []func() ([]listedComponent, error)
+	if caps != nil && caps.Tools != nil {
+		lists = append(lists, func() ([]listedComponent, error) {
+			return listKind(ctx, s, rec, KindTool, "tools/list", "tools", s.client.Tools,
+				func(tool *mcp.Tool) (string, int, error) {
+					stats, err := a.counter.AnalyzeTool(tool)
+					return tool.Name, stats.TotalTokens, err
+				})
+		})
+	}
+	if caps != nil && caps.Prompts != nil {
+		lists = append(lists, func() ([]listedComponent, error) {
+			return listKind(ctx, s, rec, KindPrompt, "prompts/list", "prompts", s.client.Prompts,
+				func(prompt *mcp.Prompt) (string, int, error) {
+					stats, err := a.counter.AnalyzePrompt(prompt)
+					return prompt.Name, stats.TotalTokens, err
+				})
+		})
+	}
+	if caps != nil && caps.Resources != nil {
+		lists = append(lists, func() ([]listedComponent, error) {
+			return listKind(ctx, s, rec, KindResource, "resources/list", "resources", s.client.Resources,
+				func(resource *mcp.Resource) (string, int, error) {
+					stats, err := a.counter.AnalyzeResource(resource)
+					return resource.Name, stats.TotalTokens, err
+				})
+		}, func() ([]listedComponent, error) {
+			return listKind(ctx, s, rec, KindResource, "resources/templates/list", "resourceTemplates", s.client.ResourceTemplates,
+				func(template *mcp.ResourceTemplate) (string, int, error) {
+					stats, err := a.counter.AnalyzeResourceTemplate(template)
+					return template.Name, stats.TotalTokens, err
+				})
+		})
+	}
+
+	for _, list := range lists {
+		components, err := list()
+		if err != nil {
+			return nil, s.client.WithStderr(err)
+		}
+		listing = append(listing, components...)
+	}
+	return listing, nil
+}
+
+// listKind lists all components of one kind with list, hashing each of them
+// from the raw result pages of method recorded by rec, where the items are
+// stored under field. describe returns the name and token count of an item.
+func listKind[P, T any](
+	ctx context.Context,
+	s *session,
+	rec *rawRecorder,
+	kind, method, field string,
+	list func(context.Context, P) iter.Seq2[*T, error],
+	describe func(*T) (string, int, error),
+) ([]listedComponent, error) {
+	listCtx, cancel := s.client.WithRequestTimeout(ctx)
+	defer cancel()
+
+	rec.take(method)
+	var items []*T
+	var zero P
+	for item, err := range list(listCtx, zero) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", kind, err)
+		}
+		items = append(items, item)
+	}
+	raws := rawItems(rec.take(method), field)
+
+	components := make([]listedComponent, 0, len(items))
+	for i, item := range items {
+		name, tokens, err := describe(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s %s: %w", kind, name, err)
+		}
+
+		// Fall back to the decoded definition if the raw pages don't
+		// line up with the items, which loses the order of object keys.
+		raw, err := json.Marshal(item)
+		if len(raws) == len(items) {
+			raw = raws[i]
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", kind, name, err)
+		}
+		component, err := hashComponent(kind, name, tokens, raw)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// rawItems returns the items stored under field in each of the raw result
+// pages, in order. Pages that cannot be decoded are skipped.
+func rawItems(pages []json.RawMessage, field string) []json.RawMessage {
+	var items []json.RawMessage
+	for _, page := range pages {
+		var result map[string]json.RawMessage
+		if json.Unmarshal(page, &result) != nil {
+			continue
+		}
+		var pageItems []json.RawMessage
+		if json.Unmarshal(result[field], &pageItems) != nil {
+			continue
+		}
+		items = append(items, pageItems...)
+	}
+	return items
+}
+
+// hashComponent hashes a component's raw definition, as encoded (ignoring
+// insignificant whitespace) and canonically with object keys sorted.
+func hashComponent(kind, name string, tokens int, raw json.RawMessage) (listedComponent, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return listedComponent{}, fmt.Errorf("invalid definition of %s %s: %w", kind, name, err)
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return listedComponent{}, fmt.Errorf("invalid definition of %s %s: %w", kind, name, err)
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return listedComponent{}, fmt.Errorf("failed to marshal %s %s: %w", kind, name, err)
+	}
+
+	return listedComponent{
+		kind:      kind,
+		name:      name,
+		tokens:    tokens,
+		canonical: sha256.Sum256(canonical),
+		raw:       sha256.Sum256(compact.Bytes()),
+	}, nil
+}
+
+// compareListings fills in the comparison of listings on result.
+func compareListings(result *StabilityResult, listings [][]listedComponent) {
+	result.Listings = len(listings)
+	if len(listings) == 0 {
+		return
+	}
+
+	var (
+		first  = make(map[string]listedComponent) // First listing of each component
+		index  = make(map[string]int)             // Index of each component in result.Components
+		counts []int                              // Listings each component appeared in
+		inRef  int                                // Components in the first listing
+	)
+	for n, listing := range listings {
+		for _, c := range listing {
+			k := c.key()
+			i, seen := index[k]
+			if !seen {
+				first[k] = c
+				index[k] = len(result.Components)
+				result.Components = append(result.Components, ComponentStability{Kind: c.kind, Name: c.name, Tokens: c.tokens})
+				result.TotalTokens += c.tokens
+				counts = append(counts, 0)
+				i = index[k]
+			}
+
+			counts[i]++
+			stability := &result.Components[i]
+			switch f := first[k]; {
+			case c.canonical != f.canonical:
+				stability.ContentChanged = true
+			case c.raw != f.raw:
+				stability.KeyOrderChanged = true
+			}
+		}
+		if n == 0 {
+			inRef = len(result.Components)
+		}
+	}
+	for i := range result.Components {
+		c := &result.Components[i]
+		c.Missing = counts[i] < len(listings)
+		if c.ContentChanged {
+			c.KeyOrderChanged = false
+		}
+	}
+
+	for _, kind := range []string{KindTool, KindPrompt, KindResource} {
+		if reordered(listings, kind) {
+			result.ReorderedKinds = append(result.ReorderedKinds, kind)
+		}
+	}
+
+	// Everything from the first difference to the first listing onward
+	// busts the cache, as do components missing from the first listing.
+	ref := listings[0]
+	diff := len(ref)
+	for _, listing := range listings[1:] {
+		i := 0
+		for i < diff && i < len(listing) && listing[i].key() == ref[i].key() && listing[i].raw == ref[i].raw {
+			i++
+		}
+		diff = i
+	}
+	for _, c := range ref[diff:] {
+		result.CacheBustingTokens += c.tokens
+	}
+	for _, c := range result.Components[inRef:] {
+		result.CacheBustingTokens += c.Tokens
+	}
+}
+
+// reordered reports whether the components of kind that all listings have
+// in common were listed in different orders.
+func reordered(listings [][]listedComponent, kind string) bool {
+	counts := make(map[string]int)
+	for _, listing := range listings {
+		for _, c := range listing {
+			if c.kind == kind {
+				counts[c.name]++
+			}
+		}
+	}
+
+	var ref []string
+	for i, listing := range listings {
+		var names []string
+		for _, c := range listing {
+			if c.kind == kind && counts[c.name] == len(listings) {
+				names = append(names, c.name)
+			}
+		}
+		if i == 0 {
+			ref = names
+		} else if !slices.Equal(ref, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawRecorder collects the raw results of a client's requests by method, as
+// passed to mcpclient.ClientOptions.RawResults.
+type rawRecorder struct {
+	mu      sync.Mutex
+	results map[string][]json.RawMessage
+}
+
+func (r *rawRecorder) record(method string, result json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[method] = append(r.results[method], result)
+}
+
+// take returns and forgets the results recorded for method.
+func (r *rawRecorder) take(method string) []json.RawMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := r.results[method]
+	delete(r.results, method)
+	return results
+}
@@ -0,0 +1,208 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+func TestCompareListings(t *testing.T) {
+	component := func(kind, name string, tokens int, canonical, raw string) listedComponent {
+		return listedComponent{
+			kind:      kind,
+			name:      name,
+			tokens:    tokens,
+			canonical: sha256.Sum256([]byte(canonical)),
+			raw:       sha256.Sum256([]byte(raw)),
+		}
+	}
+	search := component(KindTool, "search", 100, "search", "search")
+	fetch := component(KindTool, "fetch", 50, "fetch", "fetch")
+	review := component(KindPrompt, "review", 20, "review", "review")
+
+	tests := []struct {
+		name          string
+		listings      [][]listedComponent
+		wantStable    bool
+		wantReordered []string
+		wantBusting   int
+		check         func(t *testing.T, r *StabilityResult)
+	}{
+		{
+			name:       "stable",
+			listings:   [][]listedComponent{{search, fetch, review}, {search, fetch, review}},
+			wantStable: true,
+		},
+		{
+			name: "content changed",
+			listings: [][]listedComponent{
+				{search, fetch, review},
+				{search, component(KindTool, "fetch", 52, "fetch at 12:01", "fetch at 12:01"), review},
+			},
+			wantBusting: 70,
+			check: func(t *testing.T, r *StabilityResult) {
+				if !r.Components[1].ContentChanged || r.Components[1].KeyOrderChanged {
+					t.Errorf("expected fetch to have changed content, got %+v", r.Components[1])
+				}
+				if r.Components[1].Tokens != 50 {
+					t.Errorf("expected tokens as first listed, got %d", r.Components[1].Tokens)
+				}
+			},
+		},
+		{
+			name: "key order changed",
+			listings: [][]listedComponent{
+				{search, fetch},
+				{component(KindTool, "search", 100, "search", "search shuffled"), fetch},
+			},
+			wantBusting: 150,
+			check: func(t *testing.T, r *StabilityResult) {
+				if r.Components[0].ContentChanged || !r.Components[0].KeyOrderChanged {
+					t.Errorf("expected search to have changed key order only, got %+v", r.Components[0])
+				}
+			},
+		},
+		{
+			name:          "reordered",
+			listings:      [][]listedComponent{{search, fetch, review}, {fetch, search, review}},
+			wantReordered: []string{KindTool},
+			wantBusting:   170,
+		},
+		{
+			name:        "component appears",
+			listings:    [][]listedComponent{{search}, {search, fetch}},
+			wantBusting: 50,
+			check: func(t *testing.T, r *StabilityResult) {
+				if !r.Components[1].Missing {
+					t.Errorf("expected fetch to be missing from a listing, got %+v", r.Components[1])
+				}
+			},
+		},
+		{
+			name:        "component disappears",
+			listings:    [][]listedComponent{{search, fetch, review}, {search, review}},
+			wantBusting: 70,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &StabilityResult{}
+			compareListings(r, tt.listings)
+
+			if r.Listings != len(tt.listings) {
+				t.Errorf("expected %d listings, got %d", len(tt.listings), r.Listings)
+			}
+			if r.Stable() != tt.wantStable {
+				t.Errorf("expected stable %v, got %v", tt.wantStable, r.Stable())
+			}
+			if !slices.Equal(r.ReorderedKinds, tt.wantReordered) {
+				t.Errorf("expected reordered kinds %v, got %v", tt.wantReordered, r.ReorderedKinds)
+			}
+			if r.CacheBustingTokens != tt.wantBusting {
+				t.Errorf("expected %d cache-busting tokens, got %d", tt.wantBusting, r.CacheBustingTokens)
+			}
+			if tt.check != nil {
+				tt.check(t, r)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_CheckStability(t *testing.T) {
+	a, err := New(&Options{Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler := func(context.Context, *mcp.CallToolRequest, struct{ Query string }) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	stable := mcp.NewServer(&mcp.Implementation{Name: "stable"}, &mcp.ServerOptions{Instructions: "Search the docs."})
+	mcp.AddTool(stable, &mcp.Tool{Name: "search", Description: "Search the docs"}, handler)
+
+	// The unstable server stamps its listings with a counter, like a
+	// timestamp, and alternates the key order of a schema.
+	unstable := mcp.NewServer(&mcp.Implementation{Name: "unstable"}, nil)
+	mcp.AddTool(unstable, &mcp.Tool{Name: "search", Description: "Search the docs"}, handler)
+	mcp.AddTool(unstable, &mcp.Tool{Name: "status", Description: "Report the status"}, handler)
+	var listings atomic.Int64
+	unstable.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			res, err := next(ctx, method, req)
+			list, ok := res.(*mcp.ListToolsResult)
+			if err != nil || !ok {
+				return res, err
+			}
+			n := listings.Add(1)
+			var tools []*mcp.Tool
+			for _, tool := range list.Tools {
+				tool := *tool
+				switch tool.Name {
+				case "search":
+					tool.InputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+					if n%2 == 0 {
+						tool.InputSchema = json.RawMessage(`{"properties":{},"type":"object"}`)
+					}
+				case "status":
+					tool.Description = fmt.Sprintf("Report the status (listing %d)", n)
+				}
+				tools = append(tools, &tool)
+			}
+			list.Tools = tools
+			return list, nil
+		}
+	})
+
+	serve := func(server *mcp.Server) string {
+		ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+		t.Cleanup(ts.Close)
+		return ts.URL
+	}
+	results := a.CheckStability(context.Background(), map[string]*config.ServerConfig{
+		"stable":   {Type: config.TransportHTTP, URL: serve(stable)},
+		"unstable": {Type: config.TransportHTTP, URL: serve(unstable)},
+	}, &StabilityOptions{Sessions: 2, Samples: 2})
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			t.Fatalf("unexpected error for %s: %v", r.Name, r.Error)
+		}
+		if r.Listings != 4 {
+			t.Errorf("expected 4 listings of %s, got %d", r.Name, r.Listings)
+		}
+	}
+
+	if s := results[0]; !s.Stable() || s.CacheBustingTokens != 0 || len(s.Components) != 2 {
+		t.Errorf("expected the stable server to be stable, got %+v", s)
+	}
+
+	u := results[1]
+	if u.Stable() {
+		t.Fatal("expected the unstable server to be unstable")
+	}
+	if len(u.Components) != 2 {
+		t.Fatalf("expected 2 components, got %+v", u.Components)
+	}
+	if search := u.Components[0]; search.Name != "search" || !search.KeyOrderChanged || search.ContentChanged {
+		t.Errorf("expected search to have changed key order only, got %+v", search)
+	}
+	if status := u.Components[1]; status.Name != "status" || !status.ContentChanged {
+		t.Errorf("expected status to have changed content, got %+v", status)
+	}
+	if u.CacheBustingTokens != u.TotalTokens {
+		t.Errorf("expected all %d tokens to bust the cache, got %d", u.TotalTokens, u.CacheBustingTokens)
+	}
+}
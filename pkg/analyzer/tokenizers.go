@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"cmp"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// BackendTiktoken is the tokenizer backend TokenCounter uses.
+const BackendTiktoken = "tiktoken"
+
+// tiktokenEncoding describes an encoding tiktoken-go can load.
+type tiktokenEncoding struct {
+	url       string // File the encoding's ranks are downloaded from
+	vocabSize int    // Including special tokens
+}
+
+// tiktokenEncodings are the encodings tiktoken-go can load. tiktoken-go does
+// not expose them, so they mirror its encoding.go.
+var tiktokenEncodings = map[string]tiktokenEncoding{
+	tiktoken.MODEL_O200K_BASE:  {url: "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken", vocabSize: 200019},
+	tiktoken.MODEL_CL100K_BASE: {url: "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken", vocabSize: 100277},
+	tiktoken.MODEL_P50K_BASE:   {url: "https://openaipublic.blob.core.windows.net/encodings/p50k_base.tiktoken", vocabSize: 50281},
+	tiktoken.MODEL_P50K_EDIT:   {url: "https://openaipublic.blob.core.windows.net/encodings/p50k_base.tiktoken", vocabSize: 50284},
+	tiktoken.MODEL_R50K_BASE:   {url: "https://openaipublic.blob.core.windows.net/encodings/r50k_base.tiktoken", vocabSize: 50257},
+}
+
+// TokenizerInfo describes a model or encoding name NewTokenCounter accepts.
+type TokenizerInfo struct {
+	Model     string // Model or encoding name, or a prefix followed by '*' matching model names
+	Encoding  string
+	Backend   string
+	VocabSize int  // Number of tokens in the encoding, including special tokens
+	Offline   bool // Encoding is cached locally and loads without a download
+}
+
+// Tokenizers returns the model and encoding names NewTokenCounter accepts,
+// sorted by encoding, with the encoding itself first. Models whose encoding
+// the backend cannot load are left out.
+func Tokenizers() []TokenizerInfo {
+	var infos []TokenizerInfo
+	add := func(model, encoding string) {
+		enc, ok := tiktokenEncodings[encoding]
+		if !ok {
+			return
+		}
+		infos = append(infos, TokenizerInfo{
+			Model:     model,
+			Encoding:  encoding,
+			Backend:   BackendTiktoken,
+			VocabSize: enc.vocabSize,
+			Offline:   isCached(enc.url),
+		})
+	}
+
+	for model, encoding := range tiktoken.MODEL_TO_ENCODING {
+		add(model, encoding)
+	}
+	for prefix, encoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		add(prefix+"*", encoding)
+	}
+	for encoding := range tiktokenEncodings {
+		add(encoding, encoding)
+	}
+
+	slices.SortFunc(infos, func(a, b TokenizerInfo) int {
+		order := func(info TokenizerInfo) int {
+			if info.Model == info.Encoding {
+				return 0
+			}
+			return 1
+		}
+		return cmp.Or(
+			strings.Compare(a.Encoding, b.Encoding),
+			cmp.Compare(order(a), order(b)),
+			strings.Compare(a.Model, b.Model),
+		)
+	})
+	return infos
+}
+
+// TokenizerCacheDir returns the directory tiktoken-go caches downloaded
+// encodings in, as configured by the TIKTOKEN_CACHE_DIR or DATA_GYM_CACHE_DIR
+// environment variables.
+func TokenizerCacheDir() string {
+	for _, env := range []string{"TIKTOKEN_CACHE_DIR", "DATA_GYM_CACHE_DIR"} {
+		if dir := strings.TrimSpace(os.Getenv(env)); dir != "" {
+			return dir
+		}
+	}
+	return filepath.Join(os.TempDir(), "data-gym-cache")
+}
+
+// isCached reports whether the file at url is in tiktoken-go's cache, which
+// names files by the SHA-1 of their URL.
+func isCached(url string) bool {
+	sum := sha1.Sum([]byte(url))
+	_, err := os.Stat(filepath.Join(TokenizerCacheDir(), hex.EncodeToString(sum[:])))
+	return err == nil
+}
@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenizers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TIKTOKEN_CACHE_DIR", dir)
+
+	sum := sha1.Sum([]byte(tiktokenEncodings["cl100k_base"].url))
+	if err := os.WriteFile(filepath.Join(dir, hex.EncodeToString(sum[:])), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := make(map[string]TokenizerInfo)
+	for _, info := range Tokenizers() {
+		infos[info.Model] = info
+		if info.Backend != BackendTiktoken {
+			t.Errorf("%s: expected backend %s, got %s", info.Model, BackendTiktoken, info.Backend)
+		}
+		if info.VocabSize <= 0 {
+			t.Errorf("%s: expected a positive vocab size, got %d", info.Model, info.VocabSize)
+		}
+	}
+
+	tests := []struct {
+		model     string
+		encoding  string
+		vocabSize int
+		offline   bool
+	}{
+		{model: "cl100k_base", encoding: "cl100k_base", vocabSize: 100277, offline: true},
+		{model: "gpt-4", encoding: "cl100k_base", vocabSize: 100277, offline: true},
+		{model: "gpt-4-*", encoding: "cl100k_base", vocabSize: 100277, offline: true},
+		{model: "gpt-4o", encoding: "o200k_base", vocabSize: 200019},
+		{model: "text-davinci-edit-001", encoding: "p50k_edit", vocabSize: 50284},
+		{model: "davinci", encoding: "r50k_base", vocabSize: 50257},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			info, ok := infos[tt.model]
+			if !ok {
+				t.Fatalf("expected model %s to be listed", tt.model)
+			}
+			if info.Encoding != tt.encoding || info.VocabSize != tt.vocabSize || info.Offline != tt.offline {
+				t.Errorf("expected %s/%d/offline=%v, got %s/%d/offline=%v",
+					tt.encoding, tt.vocabSize, tt.offline, info.Encoding, info.VocabSize, info.Offline)
+			}
+		})
+	}
+
+	// gpt2 maps to an encoding tiktoken-go cannot load.
+	if _, ok := infos["gpt2"]; ok {
+		t.Error("expected gpt2 to be left out")
+	}
+}
+
+func TestTokenizerCacheDir(t *testing.T) {
+	t.Setenv("TIKTOKEN_CACHE_DIR", "")
+	t.Setenv("DATA_GYM_CACHE_DIR", "/data-gym")
+	if got := TokenizerCacheDir(); got != "/data-gym" {
+		t.Errorf("expected DATA_GYM_CACHE_DIR, got %s", got)
+	}
+
+	t.Setenv("TIKTOKEN_CACHE_DIR", "/tiktoken")
+	if got := TokenizerCacheDir(); got != "/tiktoken" {
+		t.Errorf("expected TIKTOKEN_CACHE_DIR to take precedence, got %s", got)
+	}
+}
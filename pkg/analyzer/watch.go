@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// watchSettleDelay is how long Watch waits after a change before
+// re-analyzing, since servers often announce changes to their tools, prompts
+// and resources in quick succession.
+const watchSettleDelay = 100 * time.Millisecond
+
+// Watch analyzes servers like Analyze, but keeps their sessions open and
+// re-analyzes a server whenever it notifies that its tools, prompts or
+// resources changed, or when its session ends. update is called with the
+// initial report and with a new report whenever re-analysis changes a
+// server's token counts or error; calls are serialized and the reports must
+// not be modified. Servers that fail to
+// connect are not retried. Watch returns once ctx is done, after closing all
+// sessions.
+func (a *Analyzer) Watch(ctx context.Context, servers map[string]*config.ServerConfig, update func(*Report)) {
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]*session)
+		results  = make(map[string]*ServerResult)
+		dirty    = make(map[string]bool)
+		wake     = make(chan struct{}, 1)
+	)
+	markDirty := func(name string) {
+		mu.Lock()
+		dirty[name] = true
+		mu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	defer func() {
+		for _, s := range sessions {
+			s.close()
+		}
+	}()
+
+	concurrency := a.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for name, srv := range servers {
+		g.Go(func() error {
+			s, result := a.connect(ctx, name, srv, func() { markDirty(name) }, nil)
+			if s != nil {
+				result = a.analyzeSession(ctx, s)
+				s.ended = make(chan struct{})
+				go func() {
+					s.endErr = s.client.Wait()
+					close(s.ended)
+					markDirty(name)
+				}()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if s != nil {
+				sessions[name] = s
+			}
+			results[name] = result
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	report := func() *Report {
+		r := &Report{}
+		for _, result := range results {
+			r.Servers = append(r.Servers, result)
+		}
+		r.sort()
+		return r
+	}
+	update(report())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchSettleDelay):
+		}
+
+		mu.Lock()
+		names := dirty
+		dirty = make(map[string]bool)
+		mu.Unlock()
+
+		changed := false
+		for name := range names {
+			// A server can announce changes while a connection attempt
+			// that later fails is being set up; it has no session to
+			// re-analyze.
+			s, ok := sessions[name]
+			if !ok {
+				continue
+			}
+			result := a.reanalyze(ctx, s)
+			changed = changed || !sameFootprint(results[name], result)
+			results[name] = result
+		}
+		if changed {
+			update(report())
+		}
+	}
+}
+
+// reanalyze analyzes the server behind a watched session again, reporting
+// an error if the session has ended.
+func (a *Analyzer) reanalyze(ctx context.Context, s *session) *ServerResult {
+	select {
+	case <-s.ended:
+		err := errors.New("server session ended")
+		if s.endErr != nil {
+			err = fmt.Errorf("%w: %w", err, s.endErr)
+		}
+		return &ServerResult{
+			Name:            s.resolveName(),
+			SourceFile:      s.srv.Source.File,
+			Disabled:        s.srv.Disabled,
+			Error:           err,
+			ConnectDuration: s.connectDuration,
+		}
+	default:
+	}
+	return a.analyzeSession(ctx, s)
+}
+
+// sameFootprint reports whether two results have the same error and token
+// counts.
+func sameFootprint(a, b *ServerResult) bool {
+	if (a.Error == nil) != (b.Error == nil) || (a.Error != nil && a.Error.Error() != b.Error.Error()) {
+		return false
+	}
+	return a.Name == b.Name &&
+		a.InstructionTokens == b.InstructionTokens &&
+		slices.Equal(a.ToolStats, b.ToolStats) &&
+		slices.Equal(a.PromptStats, b.PromptStats) &&
+		slices.Equal(a.ResourceStats, b.ResourceStats)
+}
@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+func TestAnalyzer_Watch(t *testing.T) {
+	a, err := New(&Options{Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "remote"}, nil)
+	handler := func(context.Context, *mcp.CallToolRequest, struct{ Query string }) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "search", Description: "Search the docs"}, handler)
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan *Report)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Watch(ctx, map[string]*config.ServerConfig{
+			"docs": {Type: config.TransportHTTP, URL: ts.URL},
+		}, func(r *Report) { reports <- r })
+	}()
+
+	next := func() *ServerResult {
+		t.Helper()
+		select {
+		case r := <-reports:
+			if len(r.Servers) != 1 || r.Servers[0].Error != nil {
+				t.Fatalf("expected a single successful result, got %+v", r.Servers)
+			}
+			return r.Servers[0]
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a report")
+			return nil
+		}
+	}
+
+	if initial := next(); len(initial.ToolStats) != 1 {
+		t.Fatalf("expected 1 tool initially, got %d", len(initial.ToolStats))
+	}
+
+	mcp.AddTool(server, &mcp.Tool{Name: "fetch", Description: "Fetch a page"}, handler)
+	if changed := next(); len(changed.ToolStats) != 2 {
+		t.Errorf("expected 2 tools after the list changed, got %d", len(changed.ToolStats))
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Watch did not return after the context was canceled")
+	}
+}
+
+func TestAnalyzer_Watch_ConnectFailure(t *testing.T) {
+	a, err := New(&Options{Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A server that announces a tool list change while initializing and
+	// then fails the connection by answering with an unsupported protocol
+	// version.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/tools/list_changed\"}\n\n")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"protocolVersion\":\"1999-01-01\",\"capabilities\":{},\"serverInfo\":{\"name\":\"broken\",\"version\":\"1\"}}}\n\n")
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan *Report)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Watch(ctx, map[string]*config.ServerConfig{
+			"broken": {Type: config.TransportHTTP, URL: ts.URL},
+		}, func(r *Report) { reports <- r })
+	}()
+
+	select {
+	case r := <-reports:
+		if len(r.Servers) != 1 || r.Servers[0].Error == nil {
+			t.Fatalf("expected a single failed result, got %+v", r.Servers)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the initial report")
+	}
+
+	// The change notification must not trigger re-analysis of a server
+	// without a session.
+	select {
+	case r := <-reports:
+		t.Errorf("expected no further reports, got %+v", r.Servers)
+	case <-time.After(5 * watchSettleDelay):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Watch did not return after the context was canceled")
+	}
+}
+
+func TestSameFootprint(t *testing.T) {
+	base := func() *ServerResult {
+		return &ServerResult{
+			Name:              "docs",
+			InstructionTokens: 10,
+			ToolStats:         []ToolTokens{{Name: "search", TotalTokens: 42}},
+			ConnectDuration:   time.Second,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *ServerResult)
+		want   bool
+	}{
+		{name: "identical", modify: func(*ServerResult) {}, want: true},
+		{name: "connect_duration_ignored", modify: func(r *ServerResult) { r.ConnectDuration = time.Minute; r.Retries = 2 }, want: true},
+		{name: "instructions_changed", modify: func(r *ServerResult) { r.InstructionTokens = 11 }, want: false},
+		{name: "tool_changed", modify: func(r *ServerResult) { r.ToolStats[0].TotalTokens = 43 }, want: false},
+		{name: "tool_added", modify: func(r *ServerResult) { r.ToolStats = append(r.ToolStats, ToolTokens{Name: "fetch"}) }, want: false},
+		{name: "error", modify: func(r *ServerResult) { r.Error = errors.New("server session ended") }, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := base()
+			tt.modify(r)
+			if got := sameFootprint(base(), r); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
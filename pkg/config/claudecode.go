@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ClaudeCodeProjectFile is the name of the project-scoped MCP config file that
+// Claude Code reads from the root of a project.
+const ClaudeCodeProjectFile = ".mcp.json"
+
+// claudeCodeUserConfig is the subset of Claude Code's ~/.claude.json relevant
+// to MCP servers.
+type claudeCodeUserConfig struct {
+	// MCPServers holds user-scoped servers, available in every project.
+	MCPServers map[string]*ServerConfig `json:"mcpServers,omitempty"`
+
+	// Projects holds per-project settings keyed by absolute project path.
+	Projects map[string]*claudeCodeProject `json:"projects,omitempty"`
+}
+
+// claudeCodeProject is the per-project entry in ~/.claude.json.
+type claudeCodeProject struct {
+	// MCPServers holds local-scoped servers: private to the user and only
+	// available in this project.
+	MCPServers map[string]*ServerConfig `json:"mcpServers,omitempty"`
+
+	// DisabledMcpjsonServers lists project-scoped (.mcp.json) servers the
+	// user has explicitly rejected for this project.
+	DisabledMcpjsonServers []string `json:"disabledMcpjsonServers,omitempty"`
+}
+
+// LoadClaudeCodeConfig loads the MCP servers Claude Code would use in the
+// given project, merging its three scopes:
+//
+//   - user: top-level mcpServers in userConfigPath (~/.claude.json)
+//   - project: mcpServers in <projectPath>/.mcp.json
+//   - local: projects[<projectPath>].mcpServers in userConfigPath
+//
+// As in Claude Code, local servers take precedence over project servers,
+// which take precedence over user servers with the same name. Project
+// servers listed in the project's disabledMcpjsonServers are skipped.
+//
+// Either source may be omitted: pass an empty userConfigPath to read only
+// .mcp.json, or an empty projectPath to read only user-scoped servers. A
+// missing .mcp.json is not an error.
+func LoadClaudeCodeConfig(userConfigPath, projectPath string) (*Config, error) {
+	var (
+		userCfg claudeCodeUserConfig
+		project *claudeCodeProject
+	)
+
+	if userConfigPath != "" {
+		data, err := os.ReadFile(userConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Claude Code config file: %w", err)
+		}
+		if err := unmarshalJSONC(data, &userCfg); err != nil {
+			return nil, fmt.Errorf("failed to parse Claude Code config JSON: %w", err)
+		}
+		setSources(userCfg.MCPServers, userConfigPath, "mcpServers")
+	}
+
+	var projectServers map[string]*ServerConfig
+	if projectPath != "" {
+		absProject, err := filepath.Abs(projectPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve project path: %w", err)
+		}
+		project = userCfg.Projects[absProject]
+		if project != nil {
+			setSources(project.MCPServers, userConfigPath, fmt.Sprintf("projects[%q].mcpServers", absProject))
+		}
+
+		mcpJSON := filepath.Join(absProject, ClaudeCodeProjectFile)
+		projectCfg, err := LoadConfig(mcpJSON)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// No project-scoped servers.
+		case err != nil:
+			return nil, fmt.Errorf("failed to load %s: %w", mcpJSON, err)
+		default:
+			projectServers = projectCfg.MCPServers
+		}
+	}
+
+	merged := make(map[string]*ServerConfig)
+
+	for name, srv := range userCfg.MCPServers {
+		merged[name] = srv
+	}
+
+	for name, srv := range projectServers {
+		if project != nil && slices.Contains(project.DisabledMcpjsonServers, name) {
+			continue
+		}
+		merged[name] = srv
+	}
+
+	if project != nil {
+		for name, srv := range project.MCPServers {
+			merged[name] = srv
+		}
+	}
+
+	for name, srv := range merged {
+		if srv != nil {
+			srv.Name = name
+		}
+	}
+
+	return &Config{MCPServers: merged}, nil
+}
+
+// setSources records file and key.<name> as the source of every server in
+// servers.
+func setSources(servers map[string]*ServerConfig, file, key string) {
+	setSourceKeys(servers, key)
+	for _, srv := range servers {
+		if srv != nil {
+			srv.Source.File = file
+		}
+	}
+}
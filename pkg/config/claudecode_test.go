@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeClaudeCodeFixtures copies the Claude Code testdata into a temp dir,
+// keying the local-scoped project entry by the temp project's absolute path.
+// It returns the path to the user config and the project directory.
+func writeClaudeCodeFixtures(t *testing.T) (string, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	project := filepath.Join(home, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("failed to create project dir: %v", err)
+	}
+
+	userData, err := os.ReadFile("testdata/claude_code/claude.json")
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	userData = []byte(strings.ReplaceAll(string(userData), "PROJECT_PATH", project))
+	userConfig := filepath.Join(home, ".claude.json")
+	if err := os.WriteFile(userConfig, userData, 0644); err != nil {
+		t.Fatalf("failed to write user config: %v", err)
+	}
+
+	projectData, err := os.ReadFile("testdata/claude_code/mcp.json")
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ClaudeCodeProjectFile), projectData, 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	return userConfig, project
+}
+
+func TestLoadClaudeCodeConfig_ScopePrecedence(t *testing.T) {
+	userConfig, project := writeClaudeCodeFixtures(t)
+
+	cfg, err := LoadClaudeCodeConfig(userConfig, project)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+
+	mcpJSON := filepath.Join(project, ClaudeCodeProjectFile)
+	localKey := `projects["` + project + `"].mcpServers.`
+	tests := []struct {
+		name       string
+		command    string
+		url        string
+		sourceFile string
+		sourceKey  string
+	}{
+		{"shared", "from-local", "", userConfig, localKey + "shared"},        // local > project > user
+		{"filesystem", "from-project", "", mcpJSON, "mcpServers.filesystem"}, // project > user
+		{"remote", "", "https://api.example.com/mcp", mcpJSON, "mcpServers.remote"},
+		{"local-only", "", "http://localhost:3001/sse", userConfig, localKey + "local-only"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := servers[tt.name]
+			if srv == nil {
+				t.Fatalf("server %q not found", tt.name)
+			}
+			if srv.Name != tt.name {
+				t.Errorf("expected name %q, got %q", tt.name, srv.Name)
+			}
+			if srv.Command != tt.command {
+				t.Errorf("expected command %q, got %q", tt.command, srv.Command)
+			}
+			if srv.URL != tt.url {
+				t.Errorf("expected url %q, got %q", tt.url, srv.URL)
+			}
+			if srv.Source.File != tt.sourceFile || srv.Source.Key != tt.sourceKey {
+				t.Errorf("expected source %s:%s, got %s:%s", tt.sourceFile, tt.sourceKey, srv.Source.File, srv.Source.Key)
+			}
+		})
+	}
+
+	if _, ok := servers["rejected"]; ok {
+		t.Error("expected server listed in disabledMcpjsonServers to be skipped")
+	}
+	if len(servers) != 4 {
+		t.Errorf("expected 4 servers, got %d", len(servers))
+	}
+
+	cfg.InferDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+	if servers["local-only"].Type != TransportSSE {
+		t.Errorf("expected local-only to use sse transport, got %q", servers["local-only"].Type)
+	}
+}
+
+func TestLoadClaudeCodeConfig_UnknownProject(t *testing.T) {
+	userConfig, _ := writeClaudeCodeFixtures(t)
+
+	// A project with no entry in ~/.claude.json and no .mcp.json only sees
+	// user-scoped servers.
+	cfg, err := LoadClaudeCodeConfig(userConfig, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 user-scoped servers, got %d", len(servers))
+	}
+	if servers["shared"].Command != "from-user" {
+		t.Errorf("expected user-scoped shared server, got command %q", servers["shared"].Command)
+	}
+}
+
+func TestLoadClaudeCodeConfig_ProjectOnly(t *testing.T) {
+	_, project := writeClaudeCodeFixtures(t)
+
+	cfg, err := LoadClaudeCodeConfig("", project)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	// Without ~/.claude.json there is no disabledMcpjsonServers list, so
+	// every .mcp.json server is loaded.
+	servers := cfg.MergedServers()
+	if len(servers) != 4 {
+		t.Errorf("expected 4 project-scoped servers, got %d", len(servers))
+	}
+	if servers["shared"].Command != "from-project" {
+		t.Errorf("expected project-scoped shared server, got command %q", servers["shared"].Command)
+	}
+}
+
+func TestLoadClaudeCodeConfig_NotFound(t *testing.T) {
+	_, err := LoadClaudeCodeConfig("testdata/nonexistent.json", "")
+	if err == nil {
+		t.Error("expected error for nonexistent user config")
+	}
+}
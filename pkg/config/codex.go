@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// codexConfig is the subset of the Codex CLI's config.toml relevant to MCP
+// servers, which are defined as [mcp_servers.<name>] tables.
+type codexConfig struct {
+	MCPServers map[string]*codexServer `toml:"mcp_servers"`
+}
+
+// codexServer is a single [mcp_servers.<name>] table.
+type codexServer struct {
+	// stdio servers
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args"`
+	Env     map[string]string `toml:"env"`
+	EnvVars []string          `toml:"env_vars"` // forwarded from the Codex process environment
+	Cwd     string            `toml:"cwd"`
+
+	// streamable HTTP servers
+	URL               string            `toml:"url"`
+	BearerTokenEnvVar string            `toml:"bearer_token_env_var"`
+	HTTPHeaders       map[string]string `toml:"http_headers"`
+	EnvHTTPHeaders    map[string]string `toml:"env_http_headers"` // header name -> env var name
+
+	Enabled           *bool    `toml:"enabled"`
+	EnabledTools      []string `toml:"enabled_tools"`
+	DisabledTools     []string `toml:"disabled_tools"`
+	StartupTimeoutSec *float64 `toml:"startup_timeout_sec"`
+	StartupTimeoutMS  *int64   `toml:"startup_timeout_ms"` // deprecated spelling
+	ToolTimeoutSec    *float64 `toml:"tool_timeout_sec"`
+}
+
+// parseCodexConfig parses Codex config.toml bytes into a Config. References
+// to environment variables (env_vars, bearer_token_env_var, env_http_headers)
+// are translated into ${env:NAME} placeholders so they are resolved by
+// Config.Interpolate like any other config.
+func parseCodexConfig(data []byte) (*Config, error) {
+	var cc codexConfig
+	if err := toml.Unmarshal(data, &cc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, col := decodeErr.Position()
+			return nil, fmt.Errorf("failed to parse config TOML: line %d, column %d: %w", line, col, err)
+		}
+		return nil, fmt.Errorf("failed to parse config TOML: %w", err)
+	}
+
+	servers := make(map[string]*ServerConfig, len(cc.MCPServers))
+	for name, cs := range cc.MCPServers {
+		if cs == nil {
+			servers[name] = nil
+			continue
+		}
+
+		srv := &ServerConfig{
+			Name:          name,
+			Command:       cs.Command,
+			Args:          cs.Args,
+			Env:           cs.Env,
+			Cwd:           cs.Cwd,
+			URL:           cs.URL,
+			Headers:       cs.HTTPHeaders,
+			EnabledTools:  cs.EnabledTools,
+			DisabledTools: cs.DisabledTools,
+		}
+		if cs.Enabled != nil && !*cs.Enabled {
+			srv.Disabled = true
+		}
+
+		switch {
+		case cs.StartupTimeoutSec != nil:
+			srv.StartupTimeout = Duration(*cs.StartupTimeoutSec * float64(time.Second))
+		case cs.StartupTimeoutMS != nil:
+			srv.StartupTimeout = Duration(time.Duration(*cs.StartupTimeoutMS) * time.Millisecond)
+		}
+		if cs.ToolTimeoutSec != nil {
+			srv.RequestTimeout = Duration(*cs.ToolTimeoutSec * float64(time.Second))
+		}
+
+		forwardEnv(srv, cs.EnvVars)
+
+		if cs.BearerTokenEnvVar != "" || len(cs.EnvHTTPHeaders) > 0 {
+			if srv.Headers == nil {
+				srv.Headers = make(map[string]string)
+			}
+			if cs.BearerTokenEnvVar != "" {
+				srv.Headers["Authorization"] = "Bearer " + envPlaceholder(cs.BearerTokenEnvVar)
+			}
+			for header, envVar := range cs.EnvHTTPHeaders {
+				srv.Headers[header] = envPlaceholder(envVar)
+			}
+		}
+
+		servers[name] = srv
+	}
+
+	setSourceKeys(servers, "mcp_servers")
+
+	return &Config{MCPServers: servers}, nil
+}
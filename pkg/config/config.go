@@ -8,16 +8,24 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// Transport is the MCP transport type (stdio or http).
+// Transport is the MCP transport type (stdio, http, or sse).
 type Transport string
 
 const (
 	TransportStdio Transport = "stdio"
 	TransportHTTP  Transport = "http"
 
+	// TransportSSE is the legacy HTTP+SSE transport from the 2024-11-05
+	// protocol revision. It is never inferred; servers must opt in with an
+	// explicit "type": "sse".
+	TransportSSE Transport = "sse"
+
 	// TransportStreamableHTTP is an alias for TransportHTTP used by some MCP
 	// clients (Continue, Roo Code, Anthropic MCP Registry). InferDefaults
 	// normalizes it to TransportHTTP so downstream code only needs to handle
@@ -25,6 +33,13 @@ const (
 	TransportStreamableHTTP Transport = "streamable-http"
 )
 
+// transportAliases maps alternate spellings of transport types used by
+// various MCP clients to their canonical values.
+var transportAliases = map[Transport]Transport{
+	TransportStreamableHTTP: TransportHTTP,
+	"streamableHttp":        TransportHTTP, // Cline
+}
+
 // ServerConfig holds configuration for a single MCP server.
 type ServerConfig struct {
 	// Name duplicates the map key so that functions receiving a *ServerConfig
@@ -39,10 +54,133 @@ type ServerConfig struct {
 	Env     map[string]string `json:"env,omitempty"`
 	EnvFile string            `json:"envFile,omitempty"`
 
+	// Cwd is the working directory of a stdio server. Relative paths are
+	// resolved against the directory of the server's config file.
+	Cwd string `json:"cwd,omitempty"`
+
+	// StartupTimeout bounds how long the server may take to start and
+	// complete initialization. RequestTimeout bounds each listing of tools,
+	// prompts or resources, and each HTTP request. Zero uses the defaults.
+	StartupTimeout Duration `json:"startupTimeout,omitempty"`
+	RequestTimeout Duration `json:"requestTimeout,omitempty"`
+
+	// ServerURL is Windsurf's spelling of URL. ParseConfig copies it into
+	// URL when URL is not set.
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Client-side tool gating. A disabled server (Windsurf, Cline, Zed,
+	// Goose, Codex) contributes nothing to the effective footprint; tools in
+	// DisabledTools (Roo Code, Codex) are excluded from it, and when
+	// EnabledTools (Codex enabled_tools, Goose available_tools) is non-empty
+	// only the listed tools are included. See ToolEnabled.
+	Disabled      bool     `json:"disabled,omitempty"`
+	DisabledTools []string `json:"disabledTools,omitempty"`
+	EnabledTools  []string `json:"-"`
+
+	// AlwaysAllow lists tools the client runs without asking for approval
+	// (Cline, Roo Code). It does not change which tools are sent to the
+	// model, so it has no effect on the effective footprint.
+	AlwaysAllow []string `json:"alwaysAllow,omitempty"`
+
 	// Security options (parsed but NOT IMPLEMENTED - future work).
 	// When present, these generate warnings via Config.Warnings().
 	Auth *OAuthConfig `json:"auth,omitempty"`
 	TLS  *TLSConfig   `json:"tls,omitempty"`
+
+	// Source records where the server was defined. Populated during loading.
+	Source Source `json:"-"`
+
+	// parseWarnings records problems found while translating a client's
+	// format that do not prevent analysis. Reported by Config.Warnings.
+	parseWarnings []string
+}
+
+// Source identifies the config file and key a server was defined under.
+type Source struct {
+	File string // Config file path; empty for servers built from flags
+	Key  string // Key path within the file, e.g. "mcpServers.github"
+}
+
+// Location returns "path:name" for servers loaded from a config file, so
+// that messages can point back to their definition. Servers without a
+// source file are identified by name alone.
+func (s *ServerConfig) Location() string {
+	if s.Source.File == "" {
+		return s.Name
+	}
+	return s.Source.File + ":" + s.Name
+}
+
+// Dir returns the directory of the server's config file, against which
+// relative paths such as envFile are resolved. It is empty for servers
+// without a source file, so relative paths resolve from the working
+// directory.
+func (s *ServerConfig) Dir() string {
+	if s.Source.File == "" {
+		return ""
+	}
+	return filepath.Dir(s.Source.File)
+}
+
+// ToolEnabled reports whether the client exposes the named tool of this
+// server to the model, according to Disabled, EnabledTools and DisabledTools.
+func (s *ServerConfig) ToolEnabled(tool string) bool {
+	if s.Disabled {
+		return false
+	}
+	if len(s.EnabledTools) > 0 && !slices.Contains(s.EnabledTools, tool) {
+		return false
+	}
+	return !slices.Contains(s.DisabledTools, tool)
+}
+
+// serverLabel returns the quoted location of a server for use in warning
+// and error messages.
+func serverLabel(name string, srv *ServerConfig) string {
+	if srv == nil {
+		return strconv.Quote(name)
+	}
+	return strconv.Quote(srv.Location())
+}
+
+// setSourceKeys records key.<name> as the source key of every server in
+// servers.
+func setSourceKeys(servers map[string]*ServerConfig, key string) {
+	for name, srv := range servers {
+		if srv != nil {
+			srv.Source.Key = key + "." + name
+		}
+	}
+}
+
+// Duration is a time.Duration that unmarshals from JSON either as a Go
+// duration string ("30s", "1m30s") or as a number of milliseconds, the unit
+// used by most MCP clients for timeouts.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var ms float64
+	if err := json.Unmarshal(data, &ms); err == nil {
+		*d = Duration(ms * float64(time.Millisecond))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string or a number of milliseconds: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// String returns the duration formatted like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
 }
 
 // OAuthConfig holds OAuth 2.0 client credentials (Cursor format).
@@ -64,6 +202,7 @@ type TLSConfig struct {
 
 // Config represents an MCP configuration file.
 // It supports both Claude/Cursor format (mcpServers) and VS Code format (servers).
+// Other client formats (Zed, Goose) are normalized into MCPServers when parsed.
 //
 // Config is intended to be treated as read-only after parsing. The InferDefaults
 // method populates derived fields, after which the configuration should not be
@@ -71,26 +210,81 @@ type TLSConfig struct {
 type Config struct {
 	MCPServers map[string]*ServerConfig `json:"mcpServers,omitempty"` // Claude/Cursor format
 	Servers    map[string]*ServerConfig `json:"servers,omitempty"`    // VS Code format
+	Inputs     []InputConfig            `json:"inputs,omitempty"`     // VS Code ${input:id} declarations
 
 	// merged caches the result of merging MCPServers and Servers.
 	// Populated on first call to MergedServers.
 	merged map[string]*ServerConfig
+
+	// overrides records servers replaced by a later config in MergeConfigs.
+	overrides []serverOverride
+}
+
+// serverOverride is a server definition replaced by one with the same name
+// from a later config.
+type serverOverride struct {
+	name       string
+	prev, next *ServerConfig
 }
 
 // LoadConfig loads and parses an MCP configuration file from the given path.
+// The file format is detected with DetectFormat.
 func LoadConfig(path string) (*Config, error) {
+	return LoadConfigFormat(path, FormatAuto)
+}
+
+// LoadConfigFormat loads and parses an MCP configuration file in the given
+// format. FormatAuto detects the format with DetectFormat.
+func LoadConfigFormat(path string, format Format) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	return ParseConfig(data)
+	if format == FormatAuto {
+		format = DetectFormat(path, data)
+	}
+
+	cfg, err := ParseConfigFormat(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, srv := range cfg.MergedServers() {
+		if srv != nil {
+			srv.Source.File = path
+		}
+	}
+
+	return cfg, nil
+}
+
+// MergeConfigs merges several configs into one. Servers from later configs
+// take precedence over servers with the same name in earlier ones, so
+// configs should be passed from most general (e.g. global) to most specific
+// (e.g. workspace). Replaced definitions are reported by Warnings. Input
+// declarations are concatenated.
+func MergeConfigs(cfgs ...*Config) *Config {
+	merged := &Config{MCPServers: make(map[string]*ServerConfig)}
+
+	for _, cfg := range cfgs {
+		for name, srv := range cfg.MergedServers() {
+			if prev, ok := merged.MCPServers[name]; ok {
+				merged.overrides = append(merged.overrides, serverOverride{name: name, prev: prev, next: srv})
+			}
+			merged.MCPServers[name] = srv
+		}
+		merged.Inputs = append(merged.Inputs, cfg.Inputs...)
+	}
+
+	return merged
 }
 
-// ParseConfig parses MCP configuration from JSON bytes.
+// ParseConfig parses MCP configuration from JSON bytes. Comments and trailing
+// commas (JSONC) are accepted.
 func ParseConfig(data []byte) (*Config, error) {
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	if err := unmarshalJSONC(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
@@ -98,17 +292,29 @@ func ParseConfig(data []byte) (*Config, error) {
 	for name, srv := range cfg.MCPServers {
 		if srv != nil {
 			srv.Name = name
+			srv.normalizeFields()
 		}
 	}
 	for name, srv := range cfg.Servers {
 		if srv != nil {
 			srv.Name = name
+			srv.normalizeFields()
 		}
 	}
+	setSourceKeys(cfg.MCPServers, "mcpServers")
+	setSourceKeys(cfg.Servers, "servers")
 
 	return &cfg, nil
 }
 
+// normalizeFields folds client-specific field spellings into the canonical
+// ServerConfig fields.
+func (s *ServerConfig) normalizeFields() {
+	if s.URL == "" && s.ServerURL != "" {
+		s.URL = s.ServerURL
+	}
+}
+
 // MergedServers returns a unified map of all servers from both mcpServers and servers keys.
 // If both keys are present, mcpServers takes precedence for duplicate names.
 //
@@ -135,6 +341,26 @@ func (c *Config) MergedServers() map[string]*ServerConfig {
 	return result
 }
 
+// Select restricts the config to the named server, so that interpolation,
+// validation and warnings only consider the server that will be analyzed.
+// It returns an error if no server has that name.
+func (c *Config) Select(name string) error {
+	srv, ok := c.MergedServers()[name]
+	if !ok {
+		return fmt.Errorf("server %q not found in config", name)
+	}
+	c.merged = map[string]*ServerConfig{name: srv}
+
+	var overrides []serverOverride
+	for _, o := range c.overrides {
+		if o.name == name {
+			overrides = append(overrides, o)
+		}
+	}
+	c.overrides = overrides
+	return nil
+}
+
 // InferDefaults populates default values for server configurations where they
 // can be derived from other fields. In particular, it infers the transport
 // Type from the Command or URL fields when Type is not explicitly set, and
@@ -148,8 +374,8 @@ func (c *Config) InferDefaults() {
 		}
 
 		// Normalize transport aliases to canonical values.
-		if srv.Type == TransportStreamableHTTP {
-			srv.Type = TransportHTTP
+		if canonical, ok := transportAliases[srv.Type]; ok {
+			srv.Type = canonical
 		}
 
 		if srv.Type == "" {
@@ -177,7 +403,7 @@ func (c *Config) Validate() error {
 
 	for name, srv := range servers {
 		if srv == nil {
-			errs = append(errs, fmt.Sprintf("server %q: nil configuration", name))
+			errs = append(errs, fmt.Sprintf("server %s: nil configuration", serverLabel(name, srv)))
 			continue
 		}
 
@@ -185,18 +411,18 @@ func (c *Config) Validate() error {
 		switch srv.Type {
 		case TransportStdio:
 			if srv.Command == "" {
-				errs = append(errs, fmt.Sprintf("server %q: stdio transport requires 'command' field", name))
+				errs = append(errs, fmt.Sprintf("server %s: stdio transport requires 'command' field", serverLabel(name, srv)))
 			}
-		case TransportHTTP:
+		case TransportHTTP, TransportSSE:
 			if srv.URL == "" {
-				errs = append(errs, fmt.Sprintf("server %q: http transport requires 'url' field", name))
+				errs = append(errs, fmt.Sprintf("server %s: %s transport requires 'url' field", serverLabel(name, srv), srv.Type))
 			} else if err := validateURL(srv.URL); err != nil {
-				errs = append(errs, fmt.Sprintf("server %q: invalid url: %v", name, err))
+				errs = append(errs, fmt.Sprintf("server %s: invalid url: %v", serverLabel(name, srv), err))
 			}
 		case "":
-			errs = append(errs, fmt.Sprintf("server %q: cannot infer transport type (need 'command' or 'url')", name))
+			errs = append(errs, fmt.Sprintf("server %s: cannot infer transport type (need 'command' or 'url')", serverLabel(name, srv)))
 		default:
-			errs = append(errs, fmt.Sprintf("server %q: unknown transport type %q", name, srv.Type))
+			errs = append(errs, fmt.Sprintf("server %s: unknown transport type %q", serverLabel(name, srv), srv.Type))
 		}
 	}
 
@@ -230,24 +456,44 @@ func validateURL(rawURL string) error {
 func (c *Config) Warnings() []string {
 	var warnings []string
 
+	for _, o := range c.overrides {
+		warnings = append(warnings, fmt.Sprintf("server %q: definition at %s overrides %s", o.name, sourceOf(o.next), sourceOf(o.prev)))
+	}
+
 	servers := c.MergedServers()
 	for name, srv := range servers {
 		if srv == nil {
 			continue
 		}
 
+		for _, warning := range srv.parseWarnings {
+			warnings = append(warnings, fmt.Sprintf("server %s: %s", serverLabel(name, srv), warning))
+		}
+
 		if srv.Auth != nil && (srv.Auth.ClientID != "" || srv.Auth.ClientSecret != "") {
-			warnings = append(warnings, fmt.Sprintf("server %q: OAuth auth config present but not yet implemented (ignored)", name))
+			warnings = append(warnings, fmt.Sprintf("server %s: OAuth auth config present but not yet implemented (ignored)", serverLabel(name, srv)))
 		}
 
 		if srv.TLS != nil && (srv.TLS.InsecureSkipVerify || srv.TLS.CACertFile != "" || srv.TLS.ClientCertFile != "" || srv.TLS.ClientKeyFile != "") {
-			warnings = append(warnings, fmt.Sprintf("server %q: TLS config present but not yet implemented (ignored)", name))
+			warnings = append(warnings, fmt.Sprintf("server %s: TLS config present but not yet implemented (ignored)", serverLabel(name, srv)))
 		}
 	}
 
 	return warnings
 }
 
+// sourceOf describes where srv was defined for override warnings.
+func sourceOf(srv *ServerConfig) string {
+	switch {
+	case srv == nil || srv.Source.File == "":
+		return "(unknown source)"
+	case srv.Source.Key == "":
+		return srv.Location()
+	default:
+		return srv.Source.File + ":" + srv.Source.Key
+	}
+}
+
 // LoadEnvFile parses a .env file and returns the key-value pairs.
 // Lines starting with # are treated as comments. Empty lines are skipped.
 // Format: KEY=value or KEY="value" (quotes are stripped).
@@ -306,37 +552,49 @@ func LoadEnvFile(path string) (map[string]string, error) {
 // loaded first, then env map values override. The configDir is used to resolve
 // relative envFile paths.
 func MergeServerEnv(srv *ServerConfig, configDir string) (map[string]string, error) {
+	result, err := loadServerEnvFile(srv, configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Then overlay the env map (takes precedence)
+	for k, v := range srv.Env {
+		result[k] = v
+	}
+
+	return result, nil
+}
+
+// loadServerEnvFile loads the server's envFile, if any, resolving relative
+// paths against configDir. It returns an empty map when no envFile is set.
+func loadServerEnvFile(srv *ServerConfig, configDir string) (map[string]string, error) {
 	result := make(map[string]string)
 
-	// First, load envFile if specified
-	if srv.EnvFile != "" {
-		envPath := srv.EnvFile
-		if !filepath.IsAbs(envPath) {
-			envPath = filepath.Join(configDir, envPath)
-		}
-		envPath = filepath.Clean(envPath)
-
-		// Guard against path traversal: when a configDir is set,
-		// the resolved envFile path must remain within it.
-		if configDir != "" && !filepath.IsAbs(srv.EnvFile) {
-			cleanedConfigDir := filepath.Clean(configDir)
-			if !strings.HasPrefix(envPath, cleanedConfigDir+string(filepath.Separator)) && envPath != cleanedConfigDir {
-				return nil, fmt.Errorf("envFile %q resolves to %q, which is outside the config directory %q", srv.EnvFile, envPath, cleanedConfigDir)
-			}
-		}
+	if srv.EnvFile == "" {
+		return result, nil
+	}
 
-		envVars, err := LoadEnvFile(envPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load envFile %q: %w", srv.EnvFile, err)
+	envPath := srv.EnvFile
+	if !filepath.IsAbs(envPath) {
+		envPath = filepath.Join(configDir, envPath)
+	}
+	envPath = filepath.Clean(envPath)
+
+	// Guard against path traversal: when a configDir is set,
+	// the resolved envFile path must remain within it.
+	if configDir != "" && !filepath.IsAbs(srv.EnvFile) {
+		cleanedConfigDir := filepath.Clean(configDir)
+		if !strings.HasPrefix(envPath, cleanedConfigDir+string(filepath.Separator)) && envPath != cleanedConfigDir {
+			return nil, fmt.Errorf("envFile %q resolves to %q, which is outside the config directory %q", srv.EnvFile, envPath, cleanedConfigDir)
 		}
+	}
 
-		for k, v := range envVars {
-			result[k] = v
-		}
+	envVars, err := LoadEnvFile(envPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load envFile %q: %w", srv.EnvFile, err)
 	}
 
-	// Then overlay the env map (takes precedence)
-	for k, v := range srv.Env {
+	for k, v := range envVars {
 		result[k] = v
 	}
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseConfig_ClaudeDesktop(t *testing.T) {
@@ -614,3 +615,252 @@ func TestMergedServers_CachedResult(t *testing.T) {
 		t.Error("cached result should contain the same pointers")
 	}
 }
+
+func TestConfig_Select(t *testing.T) {
+	cfg := &Config{
+		MCPServers: map[string]*ServerConfig{
+			"selected": {Name: "selected", Command: "server", Args: []string{"${MCP_TEST_SELECT_VAR:-fallback}"}},
+		},
+		Servers: map[string]*ServerConfig{
+			"other": {Name: "other", Command: "server", Args: []string{"${input:missing}"}},
+		},
+	}
+
+	if err := cfg.Select("missing"); err == nil {
+		t.Error("expected error selecting an unknown server")
+	}
+	if err := cfg.Select("selected"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+	if len(servers) != 1 || servers["selected"] == nil {
+		t.Fatalf("expected only the selected server, got %v", servers)
+	}
+
+	// Placeholders of servers that are not selected are not interpolated.
+	if err := cfg.Interpolate(nil); err != nil {
+		t.Fatalf("expected unselected servers to be skipped, got: %v", err)
+	}
+	if got := servers["selected"].Args[0]; got != "fallback" {
+		t.Errorf("expected selected server to be interpolated, got %q", got)
+	}
+}
+
+func TestValidate_SSE(t *testing.T) {
+	t.Run("valid_url", func(t *testing.T) {
+		cfg := &Config{
+			MCPServers: map[string]*ServerConfig{
+				"legacy": {Name: "legacy", Type: TransportSSE, URL: "http://localhost:3000/sse"},
+			},
+		}
+		cfg.InferDefaults()
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("validation failed: %v", err)
+		}
+		// SSE must not be normalized away like streamable-http.
+		if cfg.MCPServers["legacy"].Type != TransportSSE {
+			t.Errorf("expected type %q after InferDefaults, got %q", TransportSSE, cfg.MCPServers["legacy"].Type)
+		}
+	})
+
+	t.Run("missing_url", func(t *testing.T) {
+		cfg := &Config{
+			MCPServers: map[string]*ServerConfig{
+				"legacy": {Name: "legacy", Type: TransportSSE},
+			},
+		}
+		err := cfg.Validate()
+		if err == nil {
+			t.Fatal("expected error for sse without url")
+		}
+		if !strings.Contains(err.Error(), "sse transport requires 'url'") {
+			t.Errorf("expected sse-specific error, got: %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_Source(t *testing.T) {
+	path := filepath.Join("testdata", "claude_desktop.json")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	srv := cfg.MCPServers["github"]
+	if srv.Source.File != path {
+		t.Errorf("expected source file %q, got %q", path, srv.Source.File)
+	}
+	if srv.Source.Key != "mcpServers.github" {
+		t.Errorf("expected source key 'mcpServers.github', got %q", srv.Source.Key)
+	}
+	if got, want := srv.Location(), path+":github"; got != want {
+		t.Errorf("expected location %q, got %q", want, got)
+	}
+	if srv.Dir() != "testdata" {
+		t.Errorf("expected dir 'testdata', got %q", srv.Dir())
+	}
+
+	adHoc := &ServerConfig{Name: "adhoc"}
+	if adHoc.Location() != "adhoc" || adHoc.Dir() != "" {
+		t.Errorf("expected ad-hoc server to have no source, got location %q, dir %q", adHoc.Location(), adHoc.Dir())
+	}
+}
+
+// writeMergeFixture writes an mcp.json and a .env file to a new directory
+// under root and returns the config path.
+func writeMergeFixture(t *testing.T, root, name, configJSON, env string) string {
+	t.Helper()
+
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create fixture dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(env), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	path := filepath.Join(dir, "mcp.json")
+	if err := os.WriteFile(path, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestMergeConfigs(t *testing.T) {
+	root := t.TempDir()
+	globalPath := writeMergeFixture(t, root, "global", `{
+		"mcpServers": {
+			"github": {"command": "github-global", "envFile": ".env"},
+			"filesystem": {"command": "fs-server", "envFile": ".env"}
+		}
+	}`, "SCOPE=global")
+	workspacePath := writeMergeFixture(t, root, "workspace", `{
+		"inputs": [{"type": "promptString", "id": "token"}],
+		"servers": {
+			"github": {"command": "github-workspace", "envFile": ".env"}
+		}
+	}`, "SCOPE=workspace")
+
+	global, err := LoadConfig(globalPath)
+	if err != nil {
+		t.Fatalf("failed to load global config: %v", err)
+	}
+	workspace, err := LoadConfig(workspacePath)
+	if err != nil {
+		t.Fatalf("failed to load workspace config: %v", err)
+	}
+
+	cfg := MergeConfigs(global, workspace)
+	servers := cfg.MergedServers()
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(servers))
+	}
+	if len(cfg.Inputs) != 1 {
+		t.Errorf("expected 1 input, got %d", len(cfg.Inputs))
+	}
+
+	// Later configs take precedence.
+	github := servers["github"]
+	if github.Command != "github-workspace" {
+		t.Errorf("expected workspace github server, got command %q", github.Command)
+	}
+	if github.Source.File != workspacePath || github.Source.Key != "servers.github" {
+		t.Errorf("unexpected github source: %+v", github.Source)
+	}
+
+	// envFile resolves against each server's own config directory.
+	for name, want := range map[string]string{"github": "workspace", "filesystem": "global"} {
+		env, err := MergeServerEnv(servers[name], servers[name].Dir())
+		if err != nil {
+			t.Fatalf("server %q: failed to merge env: %v", name, err)
+		}
+		if env["SCOPE"] != want {
+			t.Errorf("server %q: expected SCOPE=%s, got %q", name, want, env["SCOPE"])
+		}
+	}
+
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 override warning, got %v", warnings)
+	}
+	want := `server "github": definition at ` + workspacePath + `:servers.github overrides ` + globalPath + `:mcpServers.github`
+	if warnings[0] != want {
+		t.Errorf("expected warning %q, got %q", want, warnings[0])
+	}
+}
+
+func TestValidate_ReportsLocation(t *testing.T) {
+	cfg := &Config{
+		MCPServers: map[string]*ServerConfig{
+			"broken": {
+				Name:   "broken",
+				Type:   TransportStdio,
+				Source: Source{File: "/etc/mcp.json", Key: "mcpServers.broken"},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), `server "/etc/mcp.json:broken"`) {
+		t.Errorf("expected error to point at the server's source, got: %v", err)
+	}
+}
+
+func TestServerConfig_ToolEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		srv  ServerConfig
+		tool string
+		want bool
+	}{
+		{"no_lists", ServerConfig{}, "search", true},
+		{"disabled_server", ServerConfig{Disabled: true}, "search", false},
+		{"disabled_tool", ServerConfig{DisabledTools: []string{"search"}}, "search", false},
+		{"other_disabled_tool", ServerConfig{DisabledTools: []string{"fetch"}}, "search", true},
+		{"enabled_tool", ServerConfig{EnabledTools: []string{"search"}}, "search", true},
+		{"not_in_enabled_tools", ServerConfig{EnabledTools: []string{"fetch"}}, "search", false},
+		{"disabled_overrides_enabled", ServerConfig{EnabledTools: []string{"search"}, DisabledTools: []string{"search"}}, "search", false},
+		{"always_allow_does_not_filter", ServerConfig{AlwaysAllow: []string{"fetch"}}, "search", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.srv.ToolEnabled(tt.tool); got != tt.want {
+				t.Errorf("ToolEnabled(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Timeouts(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{
+		"mcpServers": {
+			"slow": {
+				"command": "slow-server",
+				"cwd": "tools/slow",
+				"startupTimeout": "1m30s",
+				"requestTimeout": 2500
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	srv := cfg.MCPServers["slow"]
+	if srv.Cwd != "tools/slow" {
+		t.Errorf("expected cwd 'tools/slow', got %q", srv.Cwd)
+	}
+	if srv.StartupTimeout != Duration(90*time.Second) {
+		t.Errorf("expected 1m30s startup timeout, got %v", srv.StartupTimeout)
+	}
+	if srv.RequestTimeout != Duration(2500*time.Millisecond) {
+		t.Errorf("expected numeric timeout in milliseconds, got %v", srv.RequestTimeout)
+	}
+
+	if _, err := ParseConfig([]byte(`{"mcpServers": {"bad": {"command": "x", "startupTimeout": "soon"}}}`)); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// DiscoveredConfig is an MCP client config file found by Discover.
+type DiscoveredConfig struct {
+	Client string  // Human-readable client name, e.g. "Claude Desktop"
+	Path   string  // Path of the config file that was found
+	Config *Config // Parsed config; nil if Err is set
+	Err    error   // Load or parse error, if any
+}
+
+// clientConfigLocation describes where a client keeps its MCP config.
+type clientConfigLocation struct {
+	client string
+
+	// workspace marks locations inside the workspace rather than the
+	// home directory.
+	workspace bool
+
+	// paths returns the candidate config paths under dir, which is the
+	// home or workspace directory depending on the workspace field.
+	paths func(dir string) []string
+}
+
+// clientConfigLocations lists the standard Linux config locations of the
+// supported MCP clients. Claude Code is handled separately by Discover
+// because it merges several files into one config.
+var clientConfigLocations = []clientConfigLocation{
+	{
+		client: "Claude Desktop",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".config", "Claude", "claude_desktop_config.json")}
+		},
+	},
+	{
+		client: "Cursor",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".cursor", "mcp.json")}
+		},
+	},
+	{
+		client:    "Cursor (workspace)",
+		workspace: true,
+		paths: func(workspace string) []string {
+			return []string{filepath.Join(workspace, ".cursor", "mcp.json")}
+		},
+	},
+	{
+		client: "VS Code",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".config", "Code", "User", "mcp.json")}
+		},
+	},
+	{
+		client:    "VS Code (workspace)",
+		workspace: true,
+		paths: func(workspace string) []string {
+			return []string{filepath.Join(workspace, ".vscode", "mcp.json")}
+		},
+	},
+	{
+		client: "Windsurf",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")}
+		},
+	},
+	{
+		client: "Continue",
+		paths: func(home string) []string {
+			return globJSON(filepath.Join(home, ".continue", "mcpServers"))
+		},
+	},
+	{
+		client:    "Continue (workspace)",
+		workspace: true,
+		paths: func(workspace string) []string {
+			return globJSON(filepath.Join(workspace, ".continue", "mcpServers"))
+		},
+	},
+	{
+		client: "Zed",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".config", "zed", "settings.json")}
+		},
+	},
+	{
+		client: "Goose",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".config", "goose", "config.yaml")}
+		},
+	},
+	{
+		client: "Codex",
+		paths: func(home string) []string {
+			return []string{filepath.Join(home, ".codex", "config.toml")}
+		},
+	},
+}
+
+// globJSON returns the sorted *.json files in dir. Continue loads every
+// file in its mcpServers directory as a separate block.
+func globJSON(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	slices.Sort(matches)
+	return matches
+}
+
+// Discover finds the MCP config files of all supported clients under the
+// given home directory, plus workspace-level configs under workspace (which
+// may be empty to skip them). Files that do not exist are skipped; files that
+// exist but fail to load are returned with Err set so callers can report them.
+// Results are returned in a stable order.
+func Discover(home, workspace string) []DiscoveredConfig {
+	var found []DiscoveredConfig
+
+	for _, loc := range clientConfigLocations {
+		dir := home
+		if loc.workspace {
+			if workspace == "" {
+				continue
+			}
+			dir = workspace
+		}
+
+		for _, path := range loc.paths(dir) {
+			if !fileExists(path) {
+				continue
+			}
+
+			cfg, err := LoadConfig(path)
+			found = append(found, DiscoveredConfig{
+				Client: loc.client,
+				Path:   path,
+				Config: cfg,
+				Err:    err,
+			})
+		}
+	}
+
+	// Claude Code merges ~/.claude.json with the workspace's .mcp.json.
+	userConfig := filepath.Join(home, ".claude.json")
+	projectConfig := ""
+	if workspace != "" {
+		projectConfig = filepath.Join(workspace, ClaudeCodeProjectFile)
+	}
+	hasUser, hasProject := fileExists(userConfig), projectConfig != "" && fileExists(projectConfig)
+	if hasUser || hasProject {
+		dc := DiscoveredConfig{Client: "Claude Code", Path: userConfig}
+		if !hasUser {
+			userConfig = ""
+			dc.Path = projectConfig
+		}
+		dc.Config, dc.Err = LoadClaudeCodeConfig(userConfig, workspace)
+		found = append(found, dc)
+	}
+
+	return found
+}
+
+// fileExists reports whether path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
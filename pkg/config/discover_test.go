@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// installFixture copies a testdata file to dst (relative to root), creating
+// parent directories as needed.
+func installFixture(t *testing.T, root, dst, src string) {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	path := filepath.Join(root, dst)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", dst, err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	home := t.TempDir()
+	workspace := t.TempDir()
+
+	installFixture(t, home, ".config/Claude/claude_desktop_config.json", "testdata/claude_desktop.json")
+	installFixture(t, home, ".cursor/mcp.json", "testdata/cursor.json")
+	installFixture(t, home, ".config/Code/User/mcp.json", "testdata/vscode.json")
+	installFixture(t, home, ".codeium/windsurf/mcp_config.json", "testdata/windsurf.json")
+	installFixture(t, home, ".continue/mcpServers/remote.json", "testdata/continue.json")
+	installFixture(t, home, ".continue/mcpServers/local.json", "testdata/claude_desktop.json")
+	installFixture(t, home, ".config/zed/settings.json", "testdata/zed.json")
+	installFixture(t, home, ".config/goose/config.yaml", "testdata/goose.yaml")
+	installFixture(t, home, ".codex/config.toml", "testdata/codex.toml")
+	installFixture(t, workspace, ".vscode/mcp.json", "testdata/vscode_jsonc.json")
+	installFixture(t, workspace, ".mcp.json", "testdata/claude_code/mcp.json")
+
+	// A malformed config is reported rather than aborting discovery.
+	if err := os.MkdirAll(filepath.Join(workspace, ".cursor"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, ".cursor", "mcp.json"), []byte("{ not json"), 0644); err != nil {
+		t.Fatalf("failed to write broken config: %v", err)
+	}
+
+	found := Discover(home, workspace)
+
+	want := []struct {
+		client  string
+		path    string
+		servers int
+		wantErr bool
+	}{
+		{"Claude Desktop", filepath.Join(home, ".config/Claude/claude_desktop_config.json"), 2, false},
+		{"Cursor", filepath.Join(home, ".cursor/mcp.json"), 2, false},
+		{"Cursor (workspace)", filepath.Join(workspace, ".cursor/mcp.json"), 0, true},
+		{"VS Code", filepath.Join(home, ".config/Code/User/mcp.json"), 2, false},
+		{"VS Code (workspace)", filepath.Join(workspace, ".vscode/mcp.json"), 2, false},
+		{"Windsurf", filepath.Join(home, ".codeium/windsurf/mcp_config.json"), 2, false},
+		{"Continue", filepath.Join(home, ".continue/mcpServers/local.json"), 2, false},
+		{"Continue", filepath.Join(home, ".continue/mcpServers/remote.json"), 2, false},
+		{"Zed", filepath.Join(home, ".config/zed/settings.json"), 2, false},
+		{"Goose", filepath.Join(home, ".config/goose/config.yaml"), 3, false},
+		{"Codex", filepath.Join(home, ".codex/config.toml"), 3, false},
+		{"Claude Code", filepath.Join(workspace, ".mcp.json"), 4, false},
+	}
+
+	if len(found) != len(want) {
+		for _, dc := range found {
+			t.Logf("found %s: %s", dc.Client, dc.Path)
+		}
+		t.Fatalf("expected %d discovered configs, got %d", len(want), len(found))
+	}
+
+	for i, w := range want {
+		dc := found[i]
+		if dc.Client != w.client || dc.Path != w.path {
+			t.Errorf("config %d: expected %s at %s, got %s at %s", i, w.client, w.path, dc.Client, dc.Path)
+			continue
+		}
+		if w.wantErr {
+			if dc.Err == nil {
+				t.Errorf("%s: expected load error", w.client)
+			}
+			continue
+		}
+		if dc.Err != nil {
+			t.Errorf("%s: unexpected error: %v", w.client, dc.Err)
+			continue
+		}
+		if n := len(dc.Config.MergedServers()); n != w.servers {
+			t.Errorf("%s: expected %d servers, got %d", w.client, w.servers, n)
+		}
+	}
+}
+
+func TestDiscover_ClaudeCodeUserConfig(t *testing.T) {
+	home := t.TempDir()
+	installFixture(t, home, ".claude.json", "testdata/claude_code/claude.json")
+
+	// Without a workspace only user-scoped servers are loaded and
+	// workspace-level locations are skipped.
+	found := Discover(home, "")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 discovered config, got %d", len(found))
+	}
+
+	dc := found[0]
+	if dc.Client != "Claude Code" || dc.Path != filepath.Join(home, ".claude.json") {
+		t.Errorf("unexpected discovered config %s at %s", dc.Client, dc.Path)
+	}
+	if dc.Err != nil {
+		t.Fatalf("unexpected error: %v", dc.Err)
+	}
+	if n := len(dc.Config.MergedServers()); n != 2 {
+		t.Errorf("expected 2 user-scoped servers, got %d", n)
+	}
+}
+
+func TestDiscover_EmptyHome(t *testing.T) {
+	if found := Discover(t.TempDir(), t.TempDir()); len(found) != 0 {
+		t.Errorf("expected no configs, got %d", len(found))
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies the layout of an MCP client configuration file.
+type Format string
+
+const (
+	// FormatAuto selects the format from the file extension and contents.
+	FormatAuto Format = ""
+
+	// FormatMCPJSON is the common mcp.json layout with servers under
+	// "mcpServers" (Claude Desktop, Cursor, Windsurf, Cline, Continue) or
+	// "servers" (VS Code).
+	FormatMCPJSON Format = "mcp.json"
+
+	// FormatZed is Zed's settings.json with servers under "context_servers".
+	FormatZed Format = "zed"
+
+	// FormatGoose is Goose's config.yaml with servers under "extensions".
+	FormatGoose Format = "goose"
+
+	// FormatCodex is the Codex CLI's config.toml with servers defined as
+	// [mcp_servers.<name>] tables.
+	FormatCodex Format = "codex"
+)
+
+// formatProbe holds the top-level keys used to tell JSON formats apart.
+type formatProbe struct {
+	ContextServers json.RawMessage `json:"context_servers"`
+}
+
+// DetectFormat determines the format of a config file from its path and
+// contents. YAML files are assumed to be Goose configs and TOML files Codex
+// configs; JSON files with a top-level "context_servers" key are Zed
+// settings; everything else is treated as mcp.json.
+func DetectFormat(path string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatGoose
+	case ".toml":
+		return FormatCodex
+	}
+
+	var probe formatProbe
+	if err := unmarshalJSONC(data, &probe); err == nil && probe.ContextServers != nil {
+		return FormatZed
+	}
+
+	return FormatMCPJSON
+}
+
+// ParseConfigFormat parses configuration bytes in the given format into a
+// Config. FormatAuto is not accepted here; use DetectFormat first.
+func ParseConfigFormat(data []byte, format Format) (*Config, error) {
+	switch format {
+	case FormatMCPJSON:
+		return ParseConfig(data)
+	case FormatZed:
+		return parseZedConfig(data)
+	case FormatGoose:
+		return parseGooseConfig(data)
+	case FormatCodex:
+		return parseCodexConfig(data)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		file string
+		want Format
+	}{
+		{"testdata/claude_desktop.json", FormatMCPJSON},
+		{"testdata/vscode.json", FormatMCPJSON},
+		{"testdata/vscode_jsonc.json", FormatMCPJSON},
+		{"testdata/windsurf.json", FormatMCPJSON},
+		{"testdata/cline.json", FormatMCPJSON},
+		{"testdata/zed.json", FormatZed},
+		{"testdata/goose.yaml", FormatGoose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if got := DetectFormat(tt.file, data); got != tt.want {
+				t.Errorf("DetectFormat(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigFormat_Unsupported(t *testing.T) {
+	if _, err := ParseConfigFormat([]byte("{}"), "ini"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestParseConfig_Zed(t *testing.T) {
+	cfg, err := LoadConfig("testdata/zed.json")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers (extension server skipped), got %d", len(servers))
+	}
+
+	// Nested command.path/args/env layout
+	pg := servers["postgres"]
+	if pg == nil {
+		t.Fatal("postgres server not found")
+	}
+	if pg.Name != "postgres" {
+		t.Errorf("expected name 'postgres', got %q", pg.Name)
+	}
+	if pg.Command != "npx" {
+		t.Errorf("expected command 'npx', got %q", pg.Command)
+	}
+	if len(pg.Args) != 2 {
+		t.Errorf("expected 2 args, got %d", len(pg.Args))
+	}
+	if pg.Env["DATABASE_URL"] != "postgres://localhost/mydb" {
+		t.Errorf("expected DATABASE_URL from nested env, got %q", pg.Env["DATABASE_URL"])
+	}
+	if pg.Disabled {
+		t.Error("expected postgres to be enabled")
+	}
+
+	// Flat layout with enabled: false
+	gh := servers["github"]
+	if gh == nil {
+		t.Fatal("github server not found")
+	}
+	if gh.Command != "github-mcp-server" || !slices.Equal(gh.Args, []string{"stdio"}) {
+		t.Errorf("unexpected command %q %v", gh.Command, gh.Args)
+	}
+	if gh.Env["GITHUB_TOKEN"] != "ghp_xxx" {
+		t.Errorf("expected GITHUB_TOKEN from flat env, got %q", gh.Env["GITHUB_TOKEN"])
+	}
+	if !gh.Disabled {
+		t.Error("expected github to be disabled")
+	}
+
+	cfg.InferDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+}
+
+func TestParseConfig_Windsurf(t *testing.T) {
+	cfg, err := LoadConfig("testdata/windsurf.json")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	fs := cfg.MCPServers["filesystem"]
+	if fs == nil {
+		t.Fatal("filesystem server not found")
+	}
+	if !fs.Disabled {
+		t.Error("expected filesystem to be disabled")
+	}
+
+	// serverUrl is Windsurf's spelling of url
+	remote := cfg.MCPServers["remote-api"]
+	if remote == nil {
+		t.Fatal("remote-api server not found")
+	}
+	if remote.URL != "https://api.example.com/mcp" {
+		t.Errorf("expected url from serverUrl, got %q", remote.URL)
+	}
+
+	cfg.InferDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+	if remote.Type != TransportHTTP {
+		t.Errorf("expected remote-api to be http, got %q", remote.Type)
+	}
+}
+
+func TestParseConfig_Cline(t *testing.T) {
+	cfg, err := LoadConfig("testdata/cline.json")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	weather := cfg.MCPServers["weather"]
+	if weather == nil {
+		t.Fatal("weather server not found")
+	}
+	if !slices.Equal(weather.AlwaysAllow, []string{"get_forecast", "get_alerts"}) {
+		t.Errorf("unexpected alwaysAllow %v", weather.AlwaysAllow)
+	}
+	if weather.Disabled {
+		t.Error("expected weather to be enabled")
+	}
+	if !slices.Equal(weather.DisabledTools, []string{"get_history"}) {
+		t.Errorf("unexpected disabledTools %v", weather.DisabledTools)
+	}
+
+	remote := cfg.MCPServers["remote-api"]
+	if remote == nil {
+		t.Fatal("remote-api server not found")
+	}
+	if !remote.Disabled {
+		t.Error("expected remote-api to be disabled")
+	}
+
+	// Cline's camelCase streamableHttp type is normalized to http
+	cfg.InferDefaults()
+	if remote.Type != TransportHTTP {
+		t.Errorf("expected streamableHttp normalized to %q, got %q", TransportHTTP, remote.Type)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+}
+
+func TestParseConfig_Goose(t *testing.T) {
+	t.Setenv("GITHUB_PERSONAL_ACCESS_TOKEN", "ghp_test")
+	cfg, err := LoadConfig("testdata/goose.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers (builtin skipped), got %d", len(servers))
+	}
+	if _, ok := servers["developer"]; ok {
+		t.Error("expected builtin extension to be skipped")
+	}
+
+	gh := servers["github"]
+	if gh == nil {
+		t.Fatal("github server not found")
+	}
+	if gh.Type != TransportStdio || gh.Command != "npx" || len(gh.Args) != 2 {
+		t.Errorf("unexpected github config: type=%q command=%q args=%v", gh.Type, gh.Command, gh.Args)
+	}
+	if gh.Env["GITHUB_API_URL"] != "https://api.github.com" {
+		t.Errorf("expected GITHUB_API_URL from envs, got %q", gh.Env["GITHUB_API_URL"])
+	}
+	// env_keys are passed through from the process environment
+	if got := gh.Env["GITHUB_PERSONAL_ACCESS_TOKEN"]; got != "${env:GITHUB_PERSONAL_ACCESS_TOKEN}" {
+		t.Errorf("expected env_keys placeholder, got %q", got)
+	}
+	if !slices.Equal(gh.EnabledTools, []string{"search_repositories", "get_issue"}) {
+		t.Errorf("expected available_tools as enabled tools, got %v", gh.EnabledTools)
+	}
+
+	fetch := servers["fetch"]
+	if fetch == nil {
+		t.Fatal("fetch server not found")
+	}
+	if fetch.Type != TransportSSE || fetch.URL != "http://localhost:8000/sse" {
+		t.Errorf("unexpected fetch config: type=%q url=%q", fetch.Type, fetch.URL)
+	}
+	if !fetch.Disabled {
+		t.Error("expected fetch to be disabled")
+	}
+
+	remote := servers["remote"]
+	if remote == nil {
+		t.Fatal("remote server not found")
+	}
+	if remote.Type != TransportHTTP || remote.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("unexpected remote config: type=%q headers=%v", remote.Type, remote.Headers)
+	}
+
+	cfg.InferDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+}
+
+func TestParseConfig_GooseUnsetEnvKeys(t *testing.T) {
+	// Make sure the variable is unset; t.Setenv restores it afterwards.
+	t.Setenv("GITHUB_PERSONAL_ACCESS_TOKEN", "")
+	if err := os.Unsetenv("GITHUB_PERSONAL_ACCESS_TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig("testdata/goose.yaml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	gh := cfg.MergedServers()["github"]
+	if _, ok := gh.Env["GITHUB_PERSONAL_ACCESS_TOKEN"]; ok {
+		t.Errorf("expected unset env_keys entry not to be forwarded, got %q", gh.Env["GITHUB_PERSONAL_ACCESS_TOKEN"])
+	}
+	if err := cfg.Interpolate(nil); err != nil {
+		t.Errorf("expected unset env_keys not to fail interpolation, got: %v", err)
+	}
+
+	warnings := strings.Join(cfg.Warnings(), "\n")
+	if !strings.Contains(warnings, "env_keys variable GITHUB_PERSONAL_ACCESS_TOKEN is not set") {
+		t.Errorf("expected a warning for the unset env_keys entry, got: %q", warnings)
+	}
+}
+
+func TestParseConfig_Codex(t *testing.T) {
+	t.Setenv("DOCS_API_KEY", "docs-key")
+	cfg, err := LoadConfig("testdata/codex.toml")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	servers := cfg.MergedServers()
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers, got %d", len(servers))
+	}
+
+	docs := servers["docs"]
+	if docs == nil {
+		t.Fatal("docs server not found")
+	}
+	if docs.Name != "docs" {
+		t.Errorf("expected name 'docs', got %q", docs.Name)
+	}
+	if docs.Command != "docs-server" || !slices.Equal(docs.Args, []string{"--port", "4000"}) {
+		t.Errorf("unexpected command %q %v", docs.Command, docs.Args)
+	}
+	if docs.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("expected LOG_LEVEL=debug, got %q", docs.Env["LOG_LEVEL"])
+	}
+	if got := docs.Env["DOCS_API_KEY"]; got != "${env:DOCS_API_KEY}" {
+		t.Errorf("expected env_vars placeholder, got %q", got)
+	}
+	// Unset env_vars are not forwarded rather than left unresolved.
+	if _, ok := docs.Env["MCP_TEST_UNSET_VAR"]; ok {
+		t.Errorf("expected unset env_vars entry to be skipped, got %q", docs.Env["MCP_TEST_UNSET_VAR"])
+	}
+	if docs.StartupTimeout != Duration(20*time.Second) {
+		t.Errorf("expected 20s startup timeout, got %v", docs.StartupTimeout)
+	}
+	if docs.RequestTimeout != Duration(45*time.Second) {
+		t.Errorf("expected tool_timeout_sec as 45s request timeout, got %v", docs.RequestTimeout)
+	}
+	if docs.Cwd != "/srv/docs" {
+		t.Errorf("expected cwd '/srv/docs', got %q", docs.Cwd)
+	}
+	if !slices.Equal(docs.EnabledTools, []string{"search", "fetch_page"}) || !slices.Equal(docs.DisabledTools, []string{"fetch_page"}) {
+		t.Errorf("unexpected tool lists: enabled=%v disabled=%v", docs.EnabledTools, docs.DisabledTools)
+	}
+
+	legacy := servers["legacy"]
+	if legacy == nil {
+		t.Fatal("legacy server not found")
+	}
+	if legacy.StartupTimeout != Duration(1500*time.Millisecond) {
+		t.Errorf("expected 1.5s startup timeout, got %v", legacy.StartupTimeout)
+	}
+	if !legacy.Disabled {
+		t.Error("expected legacy to be disabled")
+	}
+
+	figma := servers["figma"]
+	if figma == nil {
+		t.Fatal("figma server not found")
+	}
+	if got := figma.Headers["Authorization"]; got != "Bearer ${env:FIGMA_OAUTH_TOKEN}" {
+		t.Errorf("expected bearer token placeholder, got %q", got)
+	}
+	if got := figma.Headers["X-Figma-Region"]; got != "us-east-1" {
+		t.Errorf("expected static header, got %q", got)
+	}
+	if got := figma.Headers["X-Org-Id"]; got != "${env:FIGMA_ORG_ID}" {
+		t.Errorf("expected env header placeholder, got %q", got)
+	}
+
+	// Codex configs go through the same pipeline as JSON configs.
+	cfg.InferDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validation failed: %v", err)
+	}
+	if docs.Type != TransportStdio || figma.Type != TransportHTTP {
+		t.Errorf("unexpected inferred transports: docs=%q figma=%q", docs.Type, figma.Type)
+	}
+	// Startup timeouts are honored, so they no longer produce warnings.
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestParseConfig_CodexErrorPosition(t *testing.T) {
+	_, err := ParseConfigFormat([]byte("[mcp_servers.docs]\ncommand = \n"), FormatCodex)
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error position, got: %v", err)
+	}
+}
+
+func TestLoadConfigFormat_Explicit(t *testing.T) {
+	// An explicit format overrides extension-based detection.
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "servers.conf")
+	if err := os.WriteFile(path, []byte("[mcp_servers.docs]\ncommand = \"docs-server\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfigFormat(path, FormatCodex)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.MCPServers["docs"] == nil {
+		t.Error("expected docs server from explicit codex format")
+	}
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected auto-detection to fail for TOML without a .toml extension")
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gooseConfig is the subset of Goose's config.yaml relevant to MCP servers.
+type gooseConfig struct {
+	Extensions map[string]*gooseExtension `yaml:"extensions"`
+}
+
+// gooseExtension is a single entry in Goose's "extensions" map.
+type gooseExtension struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"` // stdio, sse, streamable_http, builtin, ...
+	Enabled *bool             `yaml:"enabled"`
+	Cmd     string            `yaml:"cmd"`
+	Args    []string          `yaml:"args"`
+	Envs    map[string]string `yaml:"envs"`
+	URI     string            `yaml:"uri"`
+	Headers map[string]string `yaml:"headers"`
+
+	// AvailableTools restricts the extension to the listed tools when
+	// non-empty.
+	AvailableTools []string `yaml:"available_tools"`
+
+	// EnvKeys names environment variables whose values Goose fetches from
+	// its secret store at launch time.
+	EnvKeys []string `yaml:"env_keys"`
+}
+
+// gooseTransports maps Goose extension types to transports. Types not listed
+// here (builtin, platform, frontend, inline_python) run inside Goose itself
+// and are skipped.
+var gooseTransports = map[string]Transport{
+	"stdio":           TransportStdio,
+	"sse":             TransportSSE,
+	"streamable_http": TransportHTTP,
+}
+
+// parseGooseConfig parses Goose config.yaml bytes into a Config.
+func parseGooseConfig(data []byte) (*Config, error) {
+	var gc gooseConfig
+	if err := yaml.Unmarshal(data, &gc); err != nil {
+		return nil, fmt.Errorf("failed to parse Goose config YAML: %w", err)
+	}
+
+	servers := make(map[string]*ServerConfig, len(gc.Extensions))
+	for name, ext := range gc.Extensions {
+		if ext == nil {
+			servers[name] = nil
+			continue
+		}
+
+		transport, ok := gooseTransports[ext.Type]
+		if !ok {
+			continue
+		}
+
+		srv := &ServerConfig{
+			Name:    name,
+			Type:    transport,
+			Command: ext.Cmd,
+			Args:    ext.Args,
+			Env:     ext.Envs,
+			URL:     ext.URI,
+			Headers: ext.Headers,
+
+			EnabledTools: ext.AvailableTools,
+		}
+		if ext.Enabled != nil && !*ext.Enabled {
+			srv.Disabled = true
+		}
+
+		// Goose resolves env_keys from its keyring; the closest equivalent
+		// here is passing them through from the process environment. Keys
+		// missing from it may well be in the keyring, so they only warrant
+		// a warning.
+		for _, key := range forwardEnv(srv, ext.EnvKeys) {
+			srv.parseWarnings = append(srv.parseWarnings, fmt.Sprintf("env_keys variable %s is not set in the environment (Goose reads it from its keyring); not forwarded", key))
+		}
+
+		servers[name] = srv
+	}
+
+	setSourceKeys(servers, "extensions")
+
+	return &Config{MCPServers: servers}, nil
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// placeholderPattern matches ${...} placeholders in config string values.
+var placeholderPattern = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// envNamePattern matches a bare ${NAME} or ${NAME:-default} placeholder body.
+var envNamePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?::-(.*))?$`)
+
+// InputConfig describes an input variable declared in the top-level "inputs"
+// array of a VS Code mcp.json file. Servers reference inputs with
+// ${input:<id>} placeholders.
+type InputConfig struct {
+	Type        string `json:"type,omitempty"` // e.g. "promptString"
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Password    bool   `json:"password,omitempty"`
+}
+
+// InputResolver supplies the value for an ${input:<id>} placeholder. It
+// returns ok=false when no value is available, in which case the input's
+// declared default (if any) is used. Inputs referenced by a server but not
+// declared in the config are passed with only the ID set.
+type InputResolver func(input InputConfig) (value string, ok bool, err error)
+
+// LoadInputsFile reads a JSON object mapping input IDs to values, for use
+// when resolving ${input:<id>} placeholders non-interactively.
+func LoadInputsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inputs file: %w", err)
+	}
+
+	var inputs map[string]string
+	if err := unmarshalJSONC(data, &inputs); err != nil {
+		return nil, fmt.Errorf("failed to parse inputs file: %w", err)
+	}
+
+	return inputs, nil
+}
+
+// Interpolate expands placeholders in each server's command, args, url, env
+// values and header values. Supported forms are:
+//
+//   - ${env:NAME} and ${NAME}: resolved from the server's envFile, then the
+//     process environment
+//   - ${NAME:-default}: as ${NAME}, falling back to default when unset
+//   - ${input:id}: resolved via resolveInput, then the input's declared default
+//   - VS Code's predefined variables: ${workspaceFolder} (the directory
+//     holding the server's .vscode folder or config file),
+//     ${workspaceFolderBasename}, ${userHome}, ${cwd}, ${pathSeparator} and
+//     ${/}. Editor-only variables such as ${file} have no meaning outside
+//     the editor and are left in place as written.
+//
+// Each input is resolved at most once and shared across servers. Relative
+// envFile paths are resolved against each server's own config directory
+// (ServerConfig.Dir). Every placeholder that cannot be
+// resolved is collected and reported in the returned error, so that nothing
+// is started with a literal "${...}" in its arguments. Interpolate must be
+// called before InferDefaults.
+func (c *Config) Interpolate(resolveInput InputResolver) error {
+	declared := make(map[string]InputConfig, len(c.Inputs))
+	for _, in := range c.Inputs {
+		declared[in.ID] = in
+	}
+
+	resolved := make(map[string]string)
+	lookupInput := func(id string) (string, bool, error) {
+		if v, ok := resolved[id]; ok {
+			return v, true, nil
+		}
+
+		in, isDeclared := declared[id]
+		if !isDeclared {
+			in = InputConfig{ID: id}
+		}
+
+		var (
+			v   string
+			ok  bool
+			err error
+		)
+		if resolveInput != nil {
+			v, ok, err = resolveInput(in)
+			if err != nil {
+				return "", false, fmt.Errorf("input %q: %w", id, err)
+			}
+		}
+		if !ok && in.Default != "" {
+			v, ok = in.Default, true
+		}
+		if ok {
+			resolved[id] = v
+		}
+		return v, ok, nil
+	}
+
+	servers := c.MergedServers()
+	var errs []string
+
+	// Iterate in name order so prompts and error messages are deterministic.
+	for _, name := range slices.Sorted(maps.Keys(servers)) {
+		srv := servers[name]
+		if srv == nil {
+			continue
+		}
+
+		// An unreadable envFile is not fatal here: it is reported for this
+		// server alone when the connection is set up, and any placeholder
+		// that depended on it surfaces below as unresolved.
+		fileEnv, err := loadServerEnvFile(srv, srv.Dir())
+		if err != nil {
+			fileEnv = nil
+		}
+
+		ip := &interpolator{fileEnv: fileEnv, predefined: predefinedVariables(srv), lookupInput: lookupInput}
+		srv.Command = ip.expand(srv.Command)
+		for i, arg := range srv.Args {
+			srv.Args[i] = ip.expand(arg)
+		}
+		srv.URL = ip.expand(srv.URL)
+		srv.Cwd = ip.expand(srv.Cwd)
+		for k, v := range srv.Env {
+			srv.Env[k] = ip.expand(v)
+		}
+		for k, v := range srv.Headers {
+			srv.Headers[k] = ip.expand(v)
+		}
+
+		for _, err := range ip.errs {
+			errs = append(errs, fmt.Sprintf("server %s: %v", serverLabel(name, srv), err))
+		}
+		if len(ip.unresolved) > 0 {
+			errs = append(errs, fmt.Sprintf("server %s: unresolved placeholders: %s", serverLabel(name, srv), strings.Join(ip.unresolved, ", ")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// interpolator expands placeholders for a single server, recording any that
+// could not be resolved.
+type interpolator struct {
+	fileEnv     map[string]string
+	predefined  map[string]string
+	lookupInput func(id string) (string, bool, error)
+
+	unresolved []string
+	errs       []error
+}
+
+// expand replaces every placeholder in s. Unresolvable placeholders are left
+// in place and recorded.
+func (ip *interpolator) expand(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		v, ok := ip.resolve(match[2 : len(match)-1])
+		if !ok {
+			if !slices.Contains(ip.unresolved, match) {
+				ip.unresolved = append(ip.unresolved, match)
+			}
+			return match
+		}
+		return v
+	})
+}
+
+// resolve returns the value for a placeholder body (the text between "${"
+// and "}").
+func (ip *interpolator) resolve(body string) (string, bool) {
+	if id, ok := strings.CutPrefix(body, "input:"); ok {
+		v, ok, err := ip.lookupInput(id)
+		if err != nil {
+			ip.errs = append(ip.errs, err)
+			return "", false
+		}
+		return v, ok
+	}
+
+	if v, ok := ip.predefined[body]; ok {
+		return v, true
+	}
+	if slices.Contains(editorVariables, body) || strings.HasPrefix(body, "workspaceFolder:") {
+		return "${" + body + "}", true
+	}
+
+	name, hasEnvPrefix := strings.CutPrefix(body, "env:")
+	if hasEnvPrefix {
+		return ip.lookupEnv(name)
+	}
+
+	m := envNamePattern.FindStringSubmatch(body)
+	if m == nil {
+		// Unsupported variable kinds such as ${command:...} are
+		// reported as unresolved.
+		return "", false
+	}
+	if v, ok := ip.lookupEnv(m[1]); ok {
+		return v, true
+	}
+	if strings.Contains(body, ":-") {
+		return m[2], true
+	}
+	return "", false
+}
+
+// editorVariables are VS Code predefined variables that describe editor
+// state, such as the open file. They cannot be resolved outside the editor,
+// so placeholders using them are left as written rather than reported.
+var editorVariables = []string{
+	"file", "fileWorkspaceFolder", "relativeFile", "relativeFileDirname",
+	"fileBasename", "fileBasenameNoExtension", "fileExtname", "fileDirname",
+	"fileDirnameBasename", "lineNumber", "selectedText", "execPath",
+	"defaultBuildTask",
+}
+
+// predefinedVariables returns the values of the VS Code predefined variables
+// that can be resolved for srv. The workspace folder is the parent of the
+// .vscode directory holding the server's config file, or else the config
+// file's own directory; servers without a config file use the working
+// directory.
+func predefinedVariables(srv *ServerConfig) map[string]string {
+	vars := map[string]string{
+		"pathSeparator": string(os.PathSeparator),
+		"/":             string(os.PathSeparator),
+	}
+
+	cwd, err := os.Getwd()
+	if err == nil {
+		vars["cwd"] = cwd
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		vars["userHome"] = home
+	}
+
+	workspace := srv.Dir()
+	if filepath.Base(workspace) == ".vscode" {
+		workspace = filepath.Dir(workspace)
+	}
+	if workspace == "" {
+		workspace = cwd
+	}
+	if workspace != "" {
+		if abs, err := filepath.Abs(workspace); err == nil {
+			workspace = abs
+		}
+		vars["workspaceFolder"] = workspace
+		vars["workspaceFolderBasename"] = filepath.Base(workspace)
+	}
+
+	return vars
+}
+
+// envPlaceholder returns a ${env:NAME} placeholder for the given variable.
+func envPlaceholder(name string) string {
+	return "${env:" + name + "}"
+}
+
+// forwardEnv adds ${env:NAME} placeholders to srv.Env for each named variable
+// not already set, so its value is passed through from the process
+// environment at interpolation time. Used for client formats that list
+// variables to forward rather than values. Forwarding is optional: variables
+// missing from the process environment are skipped, as the clients do, and
+// their names are returned.
+func forwardEnv(srv *ServerConfig, names []string) (unset []string) {
+	for _, name := range names {
+		if _, set := srv.Env[name]; set {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			unset = append(unset, name)
+			continue
+		}
+		if srv.Env == nil {
+			srv.Env = make(map[string]string)
+		}
+		srv.Env[name] = envPlaceholder(name)
+	}
+	return unset
+}
+
+// lookupEnv resolves an environment variable from the server's envFile, then
+// the process environment.
+func (ip *interpolator) lookupEnv(name string) (string, bool) {
+	if v, ok := ip.fileEnv[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate_VSCodeInputs(t *testing.T) {
+	t.Setenv("TEST_GITHUB_TOKEN", "ghp_from_env")
+
+	cfg, err := LoadConfig("testdata/vscode_inputs.json")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if len(cfg.Inputs) != 2 {
+		t.Fatalf("expected 2 inputs, got %d", len(cfg.Inputs))
+	}
+
+	var calls []string
+	resolve := func(in InputConfig) (string, bool, error) {
+		calls = append(calls, in.ID)
+		if in.ID == "api-key" {
+			if !in.Password {
+				t.Errorf("expected api-key input to be marked as password")
+			}
+			return "secret-key", true, nil
+		}
+		return "", false, nil
+	}
+
+	if err := cfg.Interpolate(resolve); err != nil {
+		t.Fatalf("interpolation failed: %v", err)
+	}
+
+	github := cfg.Servers["github"]
+	if got := github.Env["GITHUB_TOKEN"]; got != "ghp_from_env" {
+		t.Errorf("expected GITHUB_TOKEN from environment, got %q", got)
+	}
+	if got := github.Args[3]; got != "us-east-1" {
+		t.Errorf("expected region arg to fall back to input default, got %q", got)
+	}
+
+	remote := cfg.Servers["remote-api"]
+	if remote.URL != "http://localhost:3000/mcp" {
+		t.Errorf("expected url with ${VAR:-default} expanded, got %q", remote.URL)
+	}
+	if got := remote.Headers["Authorization"]; got != "Bearer secret-key" {
+		t.Errorf("expected Authorization header from input, got %q", got)
+	}
+
+	// Each input should be resolved exactly once.
+	if len(calls) != 2 {
+		t.Errorf("expected 2 resolver calls, got %d: %v", len(calls), calls)
+	}
+}
+
+func TestInterpolate_EnvFileTakesPrecedence(t *testing.T) {
+	t.Setenv("SHARED_TOKEN", "from-process")
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("SHARED_TOKEN=from-file"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	cfg := &Config{
+		MCPServers: map[string]*ServerConfig{
+			"test": {
+				Name:    "test",
+				Command: "server",
+				Args:    []string{"--token=${SHARED_TOKEN}"},
+				EnvFile: ".env",
+				Source:  Source{File: filepath.Join(tmpDir, "mcp.json")},
+			},
+		},
+	}
+
+	if err := cfg.Interpolate(nil); err != nil {
+		t.Fatalf("interpolation failed: %v", err)
+	}
+
+	if got := cfg.MCPServers["test"].Args[0]; got != "--token=from-file" {
+		t.Errorf("expected envFile value to take precedence, got %q", got)
+	}
+}
+
+func TestInterpolate_Unresolved(t *testing.T) {
+	cfg := &Config{
+		MCPServers: map[string]*ServerConfig{
+			"alpha": {
+				Name:    "alpha",
+				Command: "server",
+				Args:    []string{"${env:MCP_TEST_UNSET_VAR}", "${env:MCP_TEST_UNSET_VAR}"},
+			},
+			"beta": {
+				Name: "beta",
+				URL:  "https://example.com/mcp",
+				Headers: map[string]string{
+					"X-API-Key": "${input:missing}",
+					"X-Other":   "${command:pickFile}",
+				},
+			},
+		},
+	}
+
+	err := cfg.Interpolate(nil)
+	if err == nil {
+		t.Fatal("expected error for unresolved placeholders")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{
+		`server "alpha": unresolved placeholders: ${env:MCP_TEST_UNSET_VAR}`,
+		"${input:missing}",
+		"${command:pickFile}",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected error to contain %q, got: %v", want, msg)
+		}
+	}
+
+	// Duplicate placeholders within a server are reported once.
+	if n := strings.Count(msg, "MCP_TEST_UNSET_VAR"); n != 1 {
+		t.Errorf("expected MCP_TEST_UNSET_VAR to be reported once, got %d times: %v", n, msg)
+	}
+}
+
+func TestInterpolate_PredefinedVariables(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	workspace := t.TempDir()
+	cfg := &Config{
+		Servers: map[string]*ServerConfig{
+			"test": {
+				Name:    "test",
+				Command: "${userHome}${/}bin${pathSeparator}server",
+				Args:    []string{"--root=${workspaceFolder}", "--name=${workspaceFolderBasename}", "${file}"},
+				Source:  Source{File: filepath.Join(workspace, ".vscode", "mcp.json")},
+			},
+		},
+	}
+
+	if err := cfg.Interpolate(nil); err != nil {
+		t.Fatalf("interpolation failed: %v", err)
+	}
+
+	srv := cfg.Servers["test"]
+	sep := string(os.PathSeparator)
+	if want := home + sep + "bin" + sep + "server"; srv.Command != want {
+		t.Errorf("expected command %q, got %q", want, srv.Command)
+	}
+	want := []string{"--root=" + workspace, "--name=" + filepath.Base(workspace), "${file}"}
+	if strings.Join(srv.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("expected args %v, got %v", want, srv.Args)
+	}
+}
+
+func TestInterpolate_ResolverError(t *testing.T) {
+	cfg := &Config{
+		MCPServers: map[string]*ServerConfig{
+			"test": {Name: "test", Command: "server", Args: []string{"${input:token}"}},
+		},
+	}
+
+	resolve := func(InputConfig) (string, bool, error) {
+		return "", false, errors.New("prompt failed")
+	}
+
+	err := cfg.Interpolate(resolve)
+	if err == nil {
+		t.Fatal("expected error from resolver")
+	}
+	if !strings.Contains(err.Error(), "prompt failed") {
+		t.Errorf("expected resolver error to be surfaced, got: %v", err)
+	}
+}
+
+func TestLoadInputsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "inputs.json")
+	if err := os.WriteFile(path, []byte(`{"api-key": "abc123"}`), 0644); err != nil {
+		t.Fatalf("failed to write inputs file: %v", err)
+	}
+
+	inputs, err := LoadInputsFile(path)
+	if err != nil {
+		t.Fatalf("failed to load inputs file: %v", err)
+	}
+	if inputs["api-key"] != "abc123" {
+		t.Errorf("expected api-key=abc123, got %q", inputs["api-key"])
+	}
+}
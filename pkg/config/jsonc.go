@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// unmarshalJSONC decodes JSON with comments (JSONC), as used by VS Code's
+// mcp.json and Zed's settings.json, into v. Line (//) and block (/* */)
+// comments and trailing commas in objects and arrays are accepted.
+//
+// Comments and trailing commas are blanked out rather than removed, so byte
+// offsets in the sanitized input match the original. Syntax and type errors
+// are therefore reported with the line and column of the original file.
+func unmarshalJSONC(data []byte, v any) error {
+	clean, err := stripJSONC(data)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(clean, v); err != nil {
+		var (
+			syntaxErr *json.SyntaxError
+			typeErr   *json.UnmarshalTypeError
+		)
+		switch {
+		case errors.As(err, &syntaxErr):
+			// Offset is the number of bytes read before the error, so
+			// the offending character is the one just before it.
+			return positionError(data, max(syntaxErr.Offset-1, 0), err)
+		case errors.As(err, &typeErr):
+			return positionError(data, typeErr.Offset, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// stripJSONC returns a copy of data with comments and trailing commas
+// replaced by spaces. Newlines inside block comments are preserved so that
+// line numbers are unchanged.
+func stripJSONC(data []byte) ([]byte, error) {
+	out := bytes.Clone(data)
+
+	// First pass: blank out comments, skipping over string literals.
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			i = skipString(out, i)
+		case '/':
+			if i+1 >= len(out) {
+				continue
+			}
+			switch out[i+1] {
+			case '/':
+				for ; i < len(out) && out[i] != '\n'; i++ {
+					out[i] = ' '
+				}
+			case '*':
+				start := i
+				end := bytes.Index(out[i+2:], []byte("*/"))
+				if end < 0 {
+					return nil, positionError(data, int64(start), errors.New("unterminated block comment"))
+				}
+				end += i + 2 + len("*/")
+				for ; i < end; i++ {
+					if out[i] != '\n' && out[i] != '\r' {
+						out[i] = ' '
+					}
+				}
+				i--
+			}
+		}
+	}
+
+	// Second pass: blank out commas followed only by whitespace and a
+	// closing bracket. Comments are already whitespace at this point.
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			i = skipString(out, i)
+		case ',':
+			j := i + 1
+			for j < len(out) && isJSONSpace(out[j]) {
+				j++
+			}
+			if j < len(out) && (out[j] == '}' || out[j] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// skipString returns the index of the closing quote of the string literal
+// starting at data[start], or len(data)-1 if the string is unterminated (the
+// JSON decoder then reports the error).
+func skipString(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return len(data) - 1
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// positionError annotates err with the 1-based line and column of the byte at
+// the given offset in data.
+func positionError(data []byte, offset int64, err error) error {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(prefix, '\n')
+	return fmt.Errorf("line %d, column %d: %w", line, col, err)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig_JSONC(t *testing.T) {
+	data, err := os.ReadFile("testdata/vscode_jsonc.json")
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	if len(cfg.Servers) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(cfg.Servers))
+	}
+
+	pg := cfg.Servers["postgres"]
+	if pg == nil {
+		t.Fatal("postgres server not found")
+	}
+	if len(pg.Args) != 2 {
+		t.Errorf("expected 2 args, got %d: %v", len(pg.Args), pg.Args)
+	}
+	// Comment markers inside strings must be left untouched.
+	if pg.Env["DATABASE_URL"] != "postgres://localhost/mydb" {
+		t.Errorf("expected DATABASE_URL to be preserved, got %q", pg.Env["DATABASE_URL"])
+	}
+
+	remote := cfg.Servers["remote-api"]
+	if remote == nil {
+		t.Fatal("remote-api server not found")
+	}
+	if remote.URL != "http://localhost:3000/mcp/*not-a-comment*/" {
+		t.Errorf("expected url to be preserved, got %q", remote.URL)
+	}
+}
+
+func TestParseConfig_JSONCErrorPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos string
+	}{
+		{
+			name: "error_after_comments",
+			input: `{
+  // comment
+  /* block
+     comment */
+  "servers": {
+    "a": { "command": "x" }
+    "b": { "command": "y" }
+  }
+}`,
+			wantPos: "line 7, column 5",
+		},
+		{
+			name:    "unterminated_block_comment",
+			input:   "{\n  /* never closed\n}",
+			wantPos: "line 2, column 3",
+		},
+		{
+			name:    "type_error",
+			input:   "{\n  \"servers\": []\n}",
+			wantPos: "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.input))
+			if err == nil {
+				t.Fatal("expected parse error")
+			}
+			if !strings.Contains(err.Error(), tt.wantPos) {
+				t.Errorf("expected error to contain %q, got: %v", tt.wantPos, err)
+			}
+		})
+	}
+}
+
+func TestStripJSONC_PreservesOffsets(t *testing.T) {
+	input := "{\"a\": 1, // c\n\"b\": [1, 2,],}"
+	got, err := stripJSONC([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(input) {
+		t.Fatalf("expected length %d, got %d", len(input), len(got))
+	}
+	if strings.Count(string(got), "\n") != strings.Count(input, "\n") {
+		t.Errorf("expected newlines to be preserved, got %q", got)
+	}
+	want := "{\"a\": 1,     \n\"b\": [1, 2 ] }"
+	if string(got) != want {
+		t.Errorf("stripJSONC() = %q, want %q", got, want)
+	}
+}
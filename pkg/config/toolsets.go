@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ToolSet is a named group of tools from a VS Code tool sets file
+// (*.toolsets.jsonc). VS Code enables the tools of a tool set together when
+// it is selected in chat or referenced from a prompt file.
+type ToolSet struct {
+	// Tools holds tool references: a tool name, "server/tool", "server/*",
+	// a bare MCP server name (all of its tools), or the name of another
+	// tool set.
+	Tools       []string `json:"tools"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+}
+
+// LoadToolSets loads a VS Code tool sets file, which maps tool set names to
+// ToolSets. Comments and trailing commas (JSONC) are accepted.
+func LoadToolSets(path string) (map[string]ToolSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool sets file: %w", err)
+	}
+
+	var sets map[string]ToolSet
+	if err := unmarshalJSONC(data, &sets); err != nil {
+		return nil, fmt.Errorf("failed to parse tool sets JSON: %w", err)
+	}
+
+	return sets, nil
+}
+
+// ResolveToolSets returns the tool references of the named tool sets.
+// References to other tool sets in the file are expanded recursively; all
+// other references are returned as-is for matching with ToolRefMatches.
+func ResolveToolSets(sets map[string]ToolSet, names []string) ([]string, error) {
+	var refs []string
+	seen := make(map[string]bool)
+
+	var expand func(name string) error
+	expand = func(name string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		set, ok := sets[name]
+		if !ok {
+			return fmt.Errorf("tool set %q not found", name)
+		}
+		for _, ref := range set.Tools {
+			if _, isSet := sets[ref]; isSet {
+				if err := expand(ref); err != nil {
+					return err
+				}
+				continue
+			}
+			refs = append(refs, ref)
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := expand(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+// ToolRefMatches reports whether a tool set reference selects the given tool
+// of the given server.
+func ToolRefMatches(ref, server, tool string) bool {
+	if refServer, refTool, ok := strings.Cut(ref, "/"); ok {
+		return refServer == server && (refTool == "*" || refTool == tool)
+	}
+	return ref == tool || ref == server
+}
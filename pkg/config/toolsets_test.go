@@ -0,0 +1,72 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLoadToolSets(t *testing.T) {
+	sets, err := LoadToolSets("testdata/vscode.toolsets.jsonc")
+	if err != nil {
+		t.Fatalf("failed to load tool sets: %v", err)
+	}
+
+	if len(sets) != 2 {
+		t.Fatalf("expected 2 tool sets, got %d", len(sets))
+	}
+	reader := sets["gh-reader"]
+	if reader.Description != "Look up GitHub issues and repositories" || reader.Icon != "github" {
+		t.Errorf("unexpected gh-reader tool set: %+v", reader)
+	}
+
+	refs, err := ResolveToolSets(sets, []string{"research"})
+	if err != nil {
+		t.Fatalf("failed to resolve tool sets: %v", err)
+	}
+	want := []string{"github/get_issue", "github/search_repositories", "fetch", "docs/*"}
+	if !slices.Equal(refs, want) {
+		t.Errorf("expected nested tool set to be expanded to %v, got %v", want, refs)
+	}
+
+	if _, err := ResolveToolSets(sets, []string{"missing"}); err == nil {
+		t.Error("expected error for unknown tool set")
+	}
+}
+
+func TestResolveToolSets_Cycle(t *testing.T) {
+	sets := map[string]ToolSet{
+		"a": {Tools: []string{"b", "tool_a"}},
+		"b": {Tools: []string{"a", "tool_b"}},
+	}
+
+	refs, err := ResolveToolSets(sets, []string{"a"})
+	if err != nil {
+		t.Fatalf("failed to resolve tool sets: %v", err)
+	}
+	if !slices.Equal(refs, []string{"tool_b", "tool_a"}) {
+		t.Errorf("unexpected refs %v", refs)
+	}
+}
+
+func TestToolRefMatches(t *testing.T) {
+	tests := []struct {
+		ref          string
+		server, tool string
+		want         bool
+	}{
+		{"search", "github", "search", true},
+		{"github", "github", "search", true},
+		{"github/search", "github", "search", true},
+		{"github/*", "github", "search", true},
+		{"github/search", "gitlab", "search", false},
+		{"github/fetch", "github", "search", false},
+		{"fetch", "github", "search", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := ToolRefMatches(tt.ref, tt.server, tt.tool); got != tt.want {
+				t.Errorf("ToolRefMatches(%q, %q, %q) = %v, want %v", tt.ref, tt.server, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// zedSettings is the subset of Zed's settings.json relevant to MCP servers.
+type zedSettings struct {
+	ContextServers map[string]*zedContextServer `json:"context_servers"`
+}
+
+// zedContextServer is a single entry in Zed's "context_servers" map. Older
+// Zed releases nest the command as {"command": {"path", "args", "env"}};
+// newer ones use a flat {"command": "...", "args": [...], "env": {...}}
+// layout. Both are accepted.
+type zedContextServer struct {
+	// Source is "custom" for user-defined servers and "extension" for
+	// servers provided (and launched) by a Zed extension.
+	Source  string            `json:"source,omitempty"`
+	Enabled *bool             `json:"enabled,omitempty"`
+	Command zedCommand        `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// zedCommand is the command for a Zed context server, either a bare path
+// string or a {"path", "args", "env"} object.
+type zedCommand struct {
+	Path string            `json:"path"`
+	Args []string          `json:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// UnmarshalJSON accepts both the string and object forms of a Zed command.
+func (c *zedCommand) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		c.Path = path
+		return nil
+	}
+
+	type plain zedCommand
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// parseZedConfig parses Zed settings.json bytes into a Config. Extension
+// provided servers are skipped: their command is managed by the extension
+// and is not present in the settings file.
+func parseZedConfig(data []byte) (*Config, error) {
+	var settings zedSettings
+	if err := unmarshalJSONC(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse Zed settings JSON: %w", err)
+	}
+
+	servers := make(map[string]*ServerConfig, len(settings.ContextServers))
+	for name, zs := range settings.ContextServers {
+		if zs == nil {
+			servers[name] = nil
+			continue
+		}
+		if zs.Source == "extension" || (zs.Command.Path == "" && zs.URL == "") {
+			continue
+		}
+
+		srv := &ServerConfig{
+			Name:    name,
+			Command: zs.Command.Path,
+			Args:    zs.Command.Args,
+			Env:     zs.Command.Env,
+			URL:     zs.URL,
+			Headers: zs.Headers,
+		}
+		if len(zs.Args) > 0 {
+			srv.Args = zs.Args
+		}
+		if len(zs.Env) > 0 {
+			srv.Env = zs.Env
+		}
+		if zs.Enabled != nil && !*zs.Enabled {
+			srv.Disabled = true
+		}
+
+		servers[name] = srv
+	}
+
+	setSourceKeys(servers, "context_servers")
+
+	return &Config{MCPServers: servers}, nil
+}
@@ -0,0 +1,128 @@
+// Package cost estimates what it costs to carry the definitions of MCP
+// servers in every request to a model, from a local pricing file and
+// assumptions about usage.
+//
+// Pricing files are JSON objects mapping model names to prices in USD per
+// million input tokens, for uncached input and for input read from the
+// prompt cache:
+//
+//	{
+//	  "gpt-4o": {"input": 2.50, "cachedInput": 1.25},
+//	  "claude-sonnet-4": {"input": 3.00, "cachedInput": 0.30}
+//	}
+//
+// cachedInput defaults to input for models without a cache discount.
+package cost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DaysPerMonth is the length of a month in monthly estimates.
+const DaysPerMonth = 30
+
+// Price holds the prices of a model's input tokens, in USD per million tokens.
+type Price struct {
+	Input       float64
+	CachedInput float64
+}
+
+// Pricing maps model names to their prices.
+type Pricing map[string]Price
+
+// LoadPricing loads a pricing file.
+func LoadPricing(path string) (Pricing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+
+	var raw map[string]struct {
+		Input       *float64 `json:"input"`
+		CachedInput *float64 `json:"cachedInput"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing file %s: %w", path, err)
+	}
+
+	pricing := make(Pricing, len(raw))
+	var errs []error
+	for model, p := range raw {
+		if p.Input == nil {
+			errs = append(errs, fmt.Errorf("model %q: input price is required", model))
+			continue
+		}
+		price := Price{Input: *p.Input, CachedInput: *p.Input}
+		if p.CachedInput != nil {
+			price.CachedInput = *p.CachedInput
+		}
+		if price.Input < 0 || price.CachedInput < 0 {
+			errs = append(errs, fmt.Errorf("model %q: prices must not be negative", model))
+			continue
+		}
+		pricing[model] = price
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+	}
+	return pricing, nil
+}
+
+// Usage holds the assumptions about how a client uses a model.
+type Usage struct {
+	RequestsPerDay float64
+	CacheHitRatio  float64 // Fraction of input tokens read from the prompt cache (0-1)
+}
+
+// Estimator estimates the cost of input tokens sent with every request.
+type Estimator struct {
+	Model string
+	Price Price
+	Usage Usage
+}
+
+// NewEstimator creates an Estimator for model, priced from pricing.
+func NewEstimator(pricing Pricing, model string, usage Usage) (*Estimator, error) {
+	price, ok := pricing[model]
+	if !ok {
+		models := make([]string, 0, len(pricing))
+		for m := range pricing {
+			models = append(models, m)
+		}
+		slices.Sort(models)
+		return nil, fmt.Errorf("no price for model %q (priced models: %s)", model, strings.Join(models, ", "))
+	}
+	if usage.RequestsPerDay < 0 {
+		return nil, fmt.Errorf("requests per day must not be negative, got %v", usage.RequestsPerDay)
+	}
+	if usage.CacheHitRatio < 0 || usage.CacheHitRatio > 1 {
+		return nil, fmt.Errorf("cache hit ratio must be between 0 and 1, got %v", usage.CacheHitRatio)
+	}
+
+	return &Estimator{Model: model, Price: price, Usage: usage}, nil
+}
+
+// PerRequest returns the cost in USD of sending tokens input tokens with a
+// single request, blending cached and uncached prices by the cache hit ratio.
+func (e *Estimator) PerRequest(tokens int) float64 {
+	hit := e.Usage.CacheHitRatio
+	pricePerToken := (hit*e.Price.CachedInput + (1-hit)*e.Price.Input) / 1e6
+	return float64(tokens) * pricePerToken
+}
+
+// Daily returns the cost in USD of sending tokens input tokens with every
+// request for a day.
+func (e *Estimator) Daily(tokens int) float64 {
+	return e.PerRequest(tokens) * e.Usage.RequestsPerDay
+}
+
+// Monthly returns the cost in USD of sending tokens input tokens with every
+// request for a month of DaysPerMonth days.
+func (e *Estimator) Monthly(tokens int) float64 {
+	return e.Daily(tokens) * DaysPerMonth
+}
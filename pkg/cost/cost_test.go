@@ -0,0 +1,144 @@
+package cost
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePricing(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPricing(t *testing.T) {
+	path := writePricing(t, `{
+		"gpt-4o": {"input": 2.5, "cachedInput": 1.25},
+		"gpt-4": {"input": 30}
+	}`)
+
+	pricing, err := LoadPricing(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := pricing["gpt-4o"], (Price{Input: 2.5, CachedInput: 1.25}); got != want {
+		t.Errorf("expected gpt-4o price %+v, got %+v", want, got)
+	}
+	if got, want := pricing["gpt-4"], (Price{Input: 30, CachedInput: 30}); got != want {
+		t.Errorf("expected cached input to default to input, got %+v", got)
+	}
+}
+
+func TestLoadPricing_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: `gpt-4o: 2.5`},
+		{name: "missing input price", data: `{"gpt-4o": {"cachedInput": 1.25}}`},
+		{name: "negative price", data: `{"gpt-4o": {"input": 2.5, "cachedInput": -1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadPricing(writePricing(t, tt.data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadPricing(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestNewEstimator(t *testing.T) {
+	pricing := Pricing{"gpt-4o": {Input: 2.5, CachedInput: 1.25}}
+
+	tests := []struct {
+		name    string
+		model   string
+		usage   Usage
+		wantErr bool
+	}{
+		{name: "valid", model: "gpt-4o", usage: Usage{RequestsPerDay: 100, CacheHitRatio: 0.5}},
+		{name: "unpriced model", model: "gpt-4", usage: Usage{RequestsPerDay: 100}, wantErr: true},
+		{name: "negative requests", model: "gpt-4o", usage: Usage{RequestsPerDay: -1}, wantErr: true},
+		{name: "cache hit ratio above 1", model: "gpt-4o", usage: Usage{CacheHitRatio: 1.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEstimator(pricing, tt.model, tt.usage)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEstimator(t *testing.T) {
+	pricing := Pricing{"model": {Input: 3, CachedInput: 0.3}}
+
+	tests := []struct {
+		name        string
+		usage       Usage
+		tokens      int
+		wantRequest float64
+		wantDaily   float64
+		wantMonthly float64
+	}{
+		{
+			name:        "no cache hits",
+			usage:       Usage{RequestsPerDay: 1000},
+			tokens:      10000,
+			wantRequest: 0.03,
+			wantDaily:   30,
+			wantMonthly: 900,
+		},
+		{
+			name:        "all cache hits",
+			usage:       Usage{RequestsPerDay: 1000, CacheHitRatio: 1},
+			tokens:      10000,
+			wantRequest: 0.003,
+			wantDaily:   3,
+			wantMonthly: 90,
+		},
+		{
+			name:        "half cache hits",
+			usage:       Usage{RequestsPerDay: 200, CacheHitRatio: 0.5},
+			tokens:      1_000_000,
+			wantRequest: 1.65,
+			wantDaily:   330,
+			wantMonthly: 9900,
+		},
+		{
+			name:   "no tokens",
+			usage:  Usage{RequestsPerDay: 1000},
+			tokens: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEstimator(pricing, "model", tt.usage)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, c := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"per request", e.PerRequest(tt.tokens), tt.wantRequest},
+				{"daily", e.Daily(tt.tokens), tt.wantDaily},
+				{"monthly", e.Monthly(tt.tokens), tt.wantMonthly},
+			} {
+				if math.Abs(c.got-c.want) > 1e-9 {
+					t.Errorf("expected %s cost %v, got %v", c.name, c.want, c.got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package history provides an append-only store of analysis runs, used to
+// track how the token footprint of MCP servers changes over time.
+//
+// Runs are stored as one JSON object per line in history.jsonl inside the
+// store directory, so the store can be appended to by concurrent processes,
+// inspected with standard tools, and trimmed by deleting lines.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// fileName is the name of the history file inside the store directory.
+const fileName = "history.jsonl"
+
+// Run records the results of one analysis run.
+type Run struct {
+	Time      time.Time      `json:"time"`
+	Tokenizer string         `json:"tokenizer"`
+	Servers   []ServerRecord `json:"servers"`
+}
+
+// ServerRecord records the token counts of one server in a run. Component
+// maps are keyed by tool, prompt or resource name.
+type ServerRecord struct {
+	Name          string         `json:"name"`
+	Version       string         `json:"version,omitempty"`       // Version the server reported
+	Error         string         `json:"error,omitempty"`         // Set if the server failed analysis
+	Instructions  int            `json:"instructions"`            // Tokens in the server instructions
+	Total         int            `json:"total"`                   // Total tokens of the server
+	Tools         map[string]int `json:"tools,omitempty"`         // Tokens per tool
+	Prompts       map[string]int `json:"prompts,omitempty"`       // Tokens per prompt
+	Resources     map[string]int `json:"resources,omitempty"`     // Tokens per resource and resource template
+	ExcludedTools []string       `json:"excludedTools,omitempty"` // Tools filtered out of the effective footprint
+}
+
+// NewRun creates a Run from an analysis report.
+func NewRun(report *analyzer.Report, tokenizer string, t time.Time) Run {
+	run := Run{Time: t.UTC(), Tokenizer: tokenizer}
+	for _, r := range report.Servers {
+		rec := ServerRecord{Name: r.Name}
+		if r.ServerInfo != nil {
+			rec.Version = r.ServerInfo.Version
+		}
+		if r.Error != nil {
+			rec.Error = r.Error.Error()
+			run.Servers = append(run.Servers, rec)
+			continue
+		}
+
+		rec.Instructions = r.InstructionTokens
+		rec.Total = r.TotalTokens()
+		for _, t := range r.ToolStats {
+			rec.Tools = addCount(rec.Tools, t.Name, t.TotalTokens)
+			if r.ExcludedTools[t.Name] {
+				rec.ExcludedTools = append(rec.ExcludedTools, t.Name)
+			}
+		}
+		for _, p := range r.PromptStats {
+			rec.Prompts = addCount(rec.Prompts, p.Name, p.TotalTokens)
+		}
+		for _, res := range r.ResourceStats {
+			rec.Resources = addCount(rec.Resources, res.Name, res.TotalTokens)
+		}
+		run.Servers = append(run.Servers, rec)
+	}
+	return run
+}
+
+// addCount adds n to m[name], allocating m if needed. Components that share
+// a name are summed.
+func addCount(m map[string]int, name string, n int) map[string]int {
+	if m == nil {
+		m = make(map[string]int)
+	}
+	m[name] += n
+	return m
+}
+
+// Store is a history store in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the history store in dir. The directory is created when
+// the first run is appended.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the path of the history file.
+func (s *Store) path() string {
+	return filepath.Join(s.dir, fileName)
+}
+
+// Append adds a run to the store.
+func (s *Store) Append(run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	// A single write keeps lines from concurrent writers intact.
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return f.Close()
+}
+
+// Runs returns the runs recorded at or after since, in the order they were
+// recorded. An empty store has no runs.
+func (s *Store) Runs(since time.Time) ([]Run, error) {
+	f, err := os.Open(s.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path(), line, err)
+		}
+		if !run.Time.Before(since) {
+			runs = append(runs, run)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+func TestNewRun(t *testing.T) {
+	report := &analyzer.Report{
+		Servers: []*analyzer.ServerResult{
+			{
+				Name:              "github",
+				ServerInfo:        &mcp.Implementation{Name: "github-mcp-server", Version: "1.2.0"},
+				InstructionTokens: 10,
+				TotalToolTokens:   analyzer.ToolTokens{TotalTokens: 300},
+				ToolStats: []analyzer.ToolTokens{
+					{Name: "search", TotalTokens: 100},
+					{Name: "create_issue", TotalTokens: 200},
+				},
+				TotalPromptTokens: analyzer.PromptTokens{TotalTokens: 5},
+				PromptStats:       []analyzer.PromptTokens{{Name: "triage", TotalTokens: 5}},
+				ExcludedTools:     map[string]bool{"create_issue": true},
+			},
+			{Name: "broken", Error: errors.New("connection refused")},
+		},
+	}
+
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.FixedZone("CET", 3600))
+	run := NewRun(report, "gpt-4", now)
+
+	if !run.Time.Equal(now) || run.Time.Location() != time.UTC || run.Tokenizer != "gpt-4" {
+		t.Errorf("unexpected run metadata: %v %q", run.Time, run.Tokenizer)
+	}
+	if len(run.Servers) != 2 {
+		t.Fatalf("expected 2 server records, got %d", len(run.Servers))
+	}
+
+	github := run.Servers[0]
+	if github.Version != "1.2.0" || github.Total != 315 || github.Instructions != 10 {
+		t.Errorf("unexpected record: %+v", github)
+	}
+	if github.Tools["search"] != 100 || github.Tools["create_issue"] != 200 || github.Prompts["triage"] != 5 {
+		t.Errorf("unexpected component counts: %+v", github)
+	}
+	if len(github.ExcludedTools) != 1 || github.ExcludedTools[0] != "create_issue" {
+		t.Errorf("unexpected excluded tools: %v", github.ExcludedTools)
+	}
+
+	if broken := run.Servers[1]; broken.Error != "connection refused" || broken.Total != 0 {
+		t.Errorf("unexpected failed record: %+v", broken)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	store := NewStore(dir)
+
+	runs, err := store.Runs(time.Time{})
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("expected an empty store, got %v, %v", runs, err)
+	}
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day := range 3 {
+		run := Run{
+			Time:      start.AddDate(0, 0, day),
+			Tokenizer: "gpt-4",
+			Servers:   []ServerRecord{{Name: "github", Total: 100 + day}},
+		}
+		if err := store.Append(run); err != nil {
+			t.Fatalf("failed to append run: %v", err)
+		}
+	}
+
+	runs, err = store.Runs(start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Servers[0].Total != 101 || runs[1].Servers[0].Total != 102 {
+		t.Errorf("expected the last 2 runs in order, got %+v", runs)
+	}
+}
+
+func TestStore_InvalidLine(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte("{\"time\":\"2026-03-01T00:00:00Z\"}\n\nnot json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewStore(dir).Runs(time.Time{}); err == nil {
+		t.Error("expected error for invalid line")
+	}
+}
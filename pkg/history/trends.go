@@ -0,0 +1,135 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// Trend summarizes how the total token count of a server changed over a
+// series of runs.
+type Trend struct {
+	Server       string
+	Runs         int // Successful runs the server appeared in
+	First, Last  time.Time
+	FirstVersion string
+	LastVersion  string
+	FirstTokens  int
+	LastTokens   int
+}
+
+// Delta returns the change in tokens from the first to the last run.
+func (t Trend) Delta() int {
+	return t.LastTokens - t.FirstTokens
+}
+
+// Trends returns the trend of every server that was analyzed successfully in
+// runs, sorted by name. Runs must be in chronological order.
+func Trends(runs []Run) []Trend {
+	byServer := make(map[string]*Trend)
+	for _, run := range runs {
+		for _, rec := range run.Servers {
+			if rec.Error != "" {
+				continue
+			}
+			t, ok := byServer[rec.Name]
+			if !ok {
+				t = &Trend{Server: rec.Name, First: run.Time, FirstVersion: rec.Version, FirstTokens: rec.Total}
+				byServer[rec.Name] = t
+			}
+			t.Runs++
+			t.Last, t.LastVersion, t.LastTokens = run.Time, rec.Version, rec.Total
+		}
+	}
+
+	trends := make([]Trend, 0, len(byServer))
+	for _, t := range byServer {
+		trends = append(trends, *t)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].Server < trends[j].Server
+	})
+	return trends
+}
+
+// Growth is the change in tokens of a single server component between its
+// first and last appearance in a series of runs.
+type Growth struct {
+	Server string
+	Kind   string // One of the analyzer.Kind* constants
+	Name   string // Component name; empty for instructions
+	From   int
+	To     int
+}
+
+// Delta returns the change in tokens.
+func (g Growth) Delta() int {
+	return g.To - g.From
+}
+
+// growthKey identifies a server component across runs.
+type growthKey struct {
+	server, kind, name string
+}
+
+// Growers returns up to n components whose token count grew the most between
+// their first and last appearance in runs, largest growth first. Components
+// that shrank or stayed the same are left out. Runs must be in chronological
+// order.
+func Growers(runs []Run, n int) []Growth {
+	var (
+		byKey = make(map[growthKey]*Growth)
+		keys  []growthKey
+	)
+	observe := func(key growthKey, tokens int) {
+		g, ok := byKey[key]
+		if !ok {
+			g = &Growth{Server: key.server, Kind: key.kind, Name: key.name, From: tokens}
+			byKey[key] = g
+			keys = append(keys, key)
+		}
+		g.To = tokens
+	}
+
+	for _, run := range runs {
+		for _, rec := range run.Servers {
+			if rec.Error != "" {
+				continue
+			}
+			observe(growthKey{rec.Name, analyzer.KindInstructions, ""}, rec.Instructions)
+			for name, tokens := range rec.Tools {
+				observe(growthKey{rec.Name, analyzer.KindTool, name}, tokens)
+			}
+			for name, tokens := range rec.Prompts {
+				observe(growthKey{rec.Name, analyzer.KindPrompt, name}, tokens)
+			}
+			for name, tokens := range rec.Resources {
+				observe(growthKey{rec.Name, analyzer.KindResource, name}, tokens)
+			}
+		}
+	}
+
+	var growers []Growth
+	for _, key := range keys {
+		if g := byKey[key]; g.Delta() > 0 {
+			growers = append(growers, *g)
+		}
+	}
+	sort.Slice(growers, func(i, j int) bool {
+		if growers[i].Delta() != growers[j].Delta() {
+			return growers[i].Delta() > growers[j].Delta()
+		}
+		if growers[i].Server != growers[j].Server {
+			return growers[i].Server < growers[j].Server
+		}
+		if growers[i].Kind != growers[j].Kind {
+			return growers[i].Kind < growers[j].Kind
+		}
+		return growers[i].Name < growers[j].Name
+	})
+	if n > 0 && len(growers) > n {
+		growers = growers[:n]
+	}
+	return growers
+}
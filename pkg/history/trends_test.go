@@ -0,0 +1,78 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/analyzer"
+)
+
+// testRuns returns three daily runs in which the github server grows and
+// the fetch server shrinks.
+func testRuns() []Run {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return []Run{
+		{
+			Time: start,
+			Servers: []ServerRecord{
+				{Name: "github", Version: "1.0.0", Instructions: 10, Total: 310, Tools: map[string]int{"search": 100, "create_issue": 200}},
+				{Name: "fetch", Version: "0.9", Total: 80, Tools: map[string]int{"fetch": 80}},
+			},
+		},
+		{
+			Time: start.AddDate(0, 0, 1),
+			Servers: []ServerRecord{
+				{Name: "github", Error: "connection refused"},
+				{Name: "fetch", Version: "0.9", Total: 80, Tools: map[string]int{"fetch": 80}},
+			},
+		},
+		{
+			Time: start.AddDate(0, 0, 2),
+			Servers: []ServerRecord{
+				{Name: "github", Version: "1.1.0", Instructions: 25, Total: 505, Tools: map[string]int{"search": 130, "create_issue": 200, "get_issue": 150}},
+				{Name: "fetch", Version: "1.0", Total: 60, Tools: map[string]int{"fetch": 60}},
+			},
+		},
+	}
+}
+
+func TestTrends(t *testing.T) {
+	runs := testRuns()
+	trends := Trends(runs)
+
+	if len(trends) != 2 {
+		t.Fatalf("expected 2 trends, got %d", len(trends))
+	}
+
+	fetch, github := trends[0], trends[1]
+	if fetch.Server != "fetch" || fetch.Runs != 3 || fetch.Delta() != -20 || fetch.FirstVersion != "0.9" || fetch.LastVersion != "1.0" {
+		t.Errorf("unexpected fetch trend: %+v", fetch)
+	}
+	if github.Server != "github" || github.Runs != 2 || github.Delta() != 195 {
+		t.Errorf("unexpected github trend: %+v", github)
+	}
+	if !github.First.Equal(runs[0].Time) || !github.Last.Equal(runs[2].Time) {
+		t.Errorf("unexpected github trend window: %v to %v", github.First, github.Last)
+	}
+}
+
+func TestGrowers(t *testing.T) {
+	growers := Growers(testRuns(), 0)
+
+	want := []Growth{
+		{Server: "github", Kind: analyzer.KindTool, Name: "search", From: 100, To: 130},
+		{Server: "github", Kind: analyzer.KindInstructions, From: 10, To: 25},
+	}
+	if len(growers) != len(want) {
+		t.Fatalf("expected %d growers, got %+v", len(want), growers)
+	}
+	for i := range want {
+		if growers[i] != want[i] {
+			t.Errorf("grower %d = %+v, want %+v", i, growers[i], want[i])
+		}
+	}
+
+	if limited := Growers(testRuns(), 1); len(limited) != 1 || limited[0].Name != "search" {
+		t.Errorf("expected only the biggest grower, got %+v", limited)
+	}
+}
@@ -1,14 +1,19 @@
 // Package mcpclient provides MCP client implementations for connecting to
-// MCP servers via stdio (command execution) and HTTP transports.
+// MCP servers via stdio (command execution), streamable HTTP, and legacy
+// HTTP+SSE transports.
 package mcpclient
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -17,33 +22,194 @@ import (
 	"github.com/tjhop/mcp-token-analyzer/pkg/config"
 )
 
-// Default timeouts for HTTP connections.
+// Default timeouts, used when ClientOptions leaves them unset.
 const (
 	defaultHTTPTimeout = 30 * time.Second
 )
 
+// ErrStartupTimeout is returned (wrapped) when a server does not complete
+// initialization within ClientOptions.StartupTimeout.
+var ErrStartupTimeout = errors.New("server startup timed out")
+
 // ClientOptions holds optional configuration for creating MCP clients.
 type ClientOptions struct {
 	Name    string            // Server identifier for multi-server output
 	Env     map[string]string // Environment variables for stdio processes
 	Headers map[string]string // HTTP headers for HTTP transport
+	Dir     string            // Working directory for stdio processes
+
+	// Stderr, if set, receives everything a stdio server writes to stderr.
+	// Independently of it, the tail of stderr is kept in memory and appended
+	// to connection errors.
+	Stderr io.Writer
+
+	// SSEFallback retries a streamable HTTP connection using the legacy
+	// HTTP+SSE transport when the server rejects the initial POST with 400,
+	// 404 or 405, per the MCP backwards compatibility guidance.
+	SSEFallback bool
+
+	// StartupTimeout bounds connection setup and initialization. A server
+	// that does not initialize in time is shut down (stdio processes are
+	// terminated) and ErrStartupTimeout is returned. Zero means no limit.
+	StartupTimeout time.Duration
+
+	// RequestTimeout bounds each HTTP request of the HTTP transports and is
+	// exposed as Client.RequestTimeout for callers to bound MCP requests.
+	// Zero uses a 30s default.
+	RequestTimeout time.Duration
+
+	// Retry is applied to connection attempts made by NewClientFromConfig
+	// and to each page request of the Client's list iterators.
+	Retry RetryPolicy
+
+	// ListChanged, if set, is called whenever the server notifies the
+	// client that its list of tools, prompts or resources changed. It is
+	// called from the session's read loop, so it must not block or make
+	// requests on the session itself.
+	ListChanged func()
+
+	// RawResults, if set, is called with the result of every successful
+	// request the client makes, exactly as the server encoded it, along
+	// with the request's method. Unlike the decoded results, it preserves
+	// the order of object keys. It is called from the session's read loop
+	// before the result is returned to the caller.
+	RawResults func(method string, result json.RawMessage)
+}
+
+// requestTimeout returns the configured request timeout or the default.
+func (o *ClientOptions) requestTimeout() time.Duration {
+	if o == nil || o.RequestTimeout <= 0 {
+		return defaultHTTPTimeout
+	}
+	return o.RequestTimeout
 }
 
 // Client wraps an MCP client session and provides a unified interface for MCP operations.
 type Client struct {
 	*mcp.ClientSession
-	Name string // Server identifier for multi-server output
+	Name           string        // Server identifier for multi-server output
+	RequestTimeout time.Duration // Timeout for individual MCP requests
+
+	// stop releases the context the session was connected with.
+	stop context.CancelFunc
+
+	retry   RetryPolicy
+	retries atomic.Int64 // Retries made by connection setup and list requests
+
+	// stderr holds the latest output of a stdio server; nil for other
+	// transports.
+	stderr *ringBuffer
+}
+
+// newClient wraps a connected session in a Client.
+func newClient(session *mcp.ClientSession, stop context.CancelFunc, opts *ClientOptions) *Client {
+	client := &Client{
+		ClientSession:  session,
+		RequestTimeout: opts.requestTimeout(),
+		stop:           stop,
+	}
+	if opts != nil {
+		client.Name = opts.Name
+		client.retry = opts.Retry
+	}
+	return client
+}
+
+// Retries returns the number of retries made so far for this client,
+// including those needed to connect.
+func (c *Client) Retries() int {
+	return int(c.retries.Load())
+}
+
+// WithStderr attaches the last lines a stdio server wrote to stderr to err,
+// as connection errors do, so that failed requests show what the server
+// logged. It returns err unchanged for other transports or if the server
+// wrote nothing.
+func (c *Client) WithStderr(err error) error {
+	return withStderrTail(err, c.stderr)
+}
+
+// withStderrTail attaches the tail of stderr (if any) to err.
+func withStderrTail(err error, stderr *ringBuffer) error {
+	if err == nil || stderr == nil {
+		return err
+	}
+	if tail := stderr.Tail(stderrTailLines); tail != "" {
+		return fmt.Errorf("%w\nserver stderr:\n%s", err, tail)
+	}
+	return err
+}
+
+// WithRequestTimeout returns a context bounded by the client's request
+// timeout, for use with a single MCP request or paginated listing.
+func (c *Client) WithRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.RequestTimeout)
 }
 
-func newMCPClient() *mcp.Client {
+func newMCPClient(opts *ClientOptions) *mcp.Client {
+	var clientOpts *mcp.ClientOptions
+	if opts != nil && opts.ListChanged != nil {
+		clientOpts = &mcp.ClientOptions{
+			ToolListChangedHandler:     func(context.Context, *mcp.ToolListChangedRequest) { opts.ListChanged() },
+			PromptListChangedHandler:   func(context.Context, *mcp.PromptListChangedRequest) { opts.ListChanged() },
+			ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) { opts.ListChanged() },
+		}
+	}
+
 	mcpClient := mcp.NewClient(&mcp.Implementation{
 		Name:    "mcp-token-analyzer",
 		Version: version.Version,
-	}, nil)
+	}, clientOpts)
 
 	return mcpClient
 }
 
+// connect connects to the server over transport and initializes the session,
+// enforcing opts.StartupTimeout. The returned stop function releases the
+// connection context and must be called once the session is closed.
+//
+// The deadline is implemented with a timer rather than context.WithTimeout
+// because the SSE transport ties its event stream to the context passed to
+// Connect: the context must stay alive after a successful startup.
+func connect(ctx context.Context, transport mcp.Transport, opts *ClientOptions) (*mcp.ClientSession, context.CancelFunc, error) {
+	var timeout time.Duration
+	if opts != nil {
+		timeout = opts.StartupTimeout
+	}
+
+	if opts != nil && opts.RawResults != nil {
+		// Streamable HTTP sessions are recorded at the HTTP layer instead;
+		// see NewHTTPClient.
+		if _, ok := transport.(*mcp.StreamableClientTransport); !ok {
+			transport = &rawResultTransport{Transport: transport, recorder: newRawResultRecorder(opts.RawResults)}
+		}
+	}
+
+	connCtx, stop := context.WithCancel(ctx)
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, stop)
+	}
+
+	session, err := newMCPClient(opts).Connect(connCtx, transport, nil)
+	if timer != nil && !timer.Stop() {
+		// The deadline passed. Connect closes the session (terminating
+		// stdio processes) when initialization fails; close it here too in
+		// case initialization finished just as the timer fired.
+		if err == nil {
+			_ = session.Close()
+		}
+		stop()
+		return nil, nil, fmt.Errorf("%w after %s", ErrStartupTimeout, timeout)
+	}
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return session, stop, nil
+}
+
 // NewStdioClient creates an MCP client that connects via stdio by executing the given command.
 // The command and args are provided separately. Pass nil for opts if no options are needed.
 func NewStdioClient(ctx context.Context, command string, args []string, opts *ClientOptions) (*Client, error) {
@@ -52,6 +218,17 @@ func NewStdioClient(ctx context.Context, command string, args []string, opts *Cl
 	}
 
 	cmd := exec.Command(command, args...)
+	if opts != nil {
+		cmd.Dir = opts.Dir
+	}
+
+	stderr := newRingBuffer(stderrBufferSize)
+	cmd.Stderr = stderr
+	if opts != nil && opts.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, opts.Stderr)
+	}
+	// Don't let a grandchild holding stderr open block shutdown.
+	cmd.WaitDelay = stderrWaitDelay
 
 	// Set environment variables if provided
 	if opts != nil && len(opts.Env) > 0 {
@@ -66,17 +243,13 @@ func NewStdioClient(ctx context.Context, command string, args []string, opts *Cl
 		Command: cmd,
 	}
 
-	mcpClient := newMCPClient()
-	session, err := mcpClient.Connect(ctx, transport, nil)
+	session, stop, err := connect(ctx, transport, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect MCP client and establish session: %w", err)
-	}
-
-	client := &Client{ClientSession: session}
-	if opts != nil {
-		client.Name = opts.Name
+		return nil, withStderrTail(fmt.Errorf("failed to connect MCP client and establish session: %w", err), stderr)
 	}
 
+	client := newClient(session, stop, opts)
+	client.stderr = stderr
 	return client, nil
 }
 
@@ -95,65 +268,205 @@ func (h *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 	return h.base.RoundTrip(reqCopy)
 }
 
-// NewHTTPClient creates an MCP client that connects via HTTP to the given URL.
-// Pass nil for opts if no options are needed.
-func NewHTTPClient(ctx context.Context, endpoint string, opts *ClientOptions) (*Client, error) {
+// postStatusRecorder wraps an http.RoundTripper and records the status code
+// of the first POST response. For streamable HTTP, the first POST is always
+// the initialize request, so this tells us how the server reacted to it.
+// It also records the status of the latest request other than a session
+// DELETE, so that connection errors can report it.
+type postStatusRecorder struct {
+	base   http.RoundTripper
+	status atomic.Int32
+	last   atomic.Int32
+}
+
+func (p *postStatusRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := p.base.RoundTrip(req)
+	if err == nil && req.Method == http.MethodPost {
+		p.status.CompareAndSwap(0, int32(resp.StatusCode))
+	}
+	if err == nil && req.Method != http.MethodDelete {
+		p.last.Store(int32(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// withStatus attaches a StatusError to err if the latest response was an
+// HTTP error, so that callers can classify the failure.
+func (p *postStatusRecorder) withStatus(err error) error {
+	if status := int(p.last.Load()); status >= 400 {
+		return fmt.Errorf("%w (%w)", err, &StatusError{StatusCode: status})
+	}
+	return err
+}
+
+// rejected reports whether the first POST was answered with 400 Bad Request,
+// 404 Not Found or 405 Method Not Allowed, which indicates a server that only
+// speaks the legacy HTTP+SSE transport. Other errors, such as failed
+// authentication, say nothing about the transport.
+func (p *postStatusRecorder) rejected() bool {
+	switch p.status.Load() {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed:
+		return true
+	default:
+		return false
+	}
+}
+
+// newHTTPTransportClient builds the *http.Client shared by the HTTP-based
+// transports, injecting any configured headers into every request.
+func newHTTPTransportClient(opts *ClientOptions) *http.Client {
 	var headers map[string]string
 	if opts != nil {
 		headers = opts.Headers
 	}
 
-	httpClient := &http.Client{
+	return &http.Client{
 		Transport: &headerRoundTripper{
 			base:    http.DefaultTransport,
 			headers: headers,
 		},
-		Timeout: defaultHTTPTimeout,
+		Timeout: opts.requestTimeout(),
 	}
+}
+
+// NewHTTPClient creates an MCP client that connects via streamable HTTP to the given URL.
+// If opts.SSEFallback is set and the server rejects the initialize request with
+// 400, 404 or 405, the connection is retried with NewSSEClient within what is
+// left of opts.StartupTimeout. If that fails as well, both errors are returned.
+// Pass nil for opts if no options are needed.
+func NewHTTPClient(ctx context.Context, endpoint string, opts *ClientOptions) (*Client, error) {
+	httpClient := newHTTPTransportClient(opts)
+	if opts != nil && opts.RawResults != nil {
+		httpClient.Transport = &rawResultRoundTripper{base: httpClient.Transport, recorder: newRawResultRecorder(opts.RawResults)}
+	}
+	recorder := &postStatusRecorder{base: httpClient.Transport}
+	httpClient.Transport = recorder
 
 	transport := &mcp.StreamableClientTransport{
 		Endpoint:   endpoint,
 		HTTPClient: httpClient,
 	}
 
-	mcpClient := newMCPClient()
-	session, err := mcpClient.Connect(ctx, transport, nil)
+	start := time.Now()
+	session, stop, err := connect(ctx, transport, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MCP server at %s: %w", endpoint, err)
+		err = recorder.withStatus(fmt.Errorf("failed to connect to MCP server at %s: %w", endpoint, err))
+		if opts != nil && opts.SSEFallback && recorder.rejected() {
+			return fallbackToSSE(ctx, endpoint, opts, time.Since(start), err)
+		}
+		return nil, err
 	}
 
-	client := &Client{ClientSession: session}
-	if opts != nil {
-		client.Name = opts.Name
+	return newClient(session, stop, opts), nil
+}
+
+// fallbackToSSE connects with NewSSEClient after the streamable HTTP attempt
+// failed with httpErr, taking elapsed off the startup timeout so that the
+// two attempts together stay within it.
+func fallbackToSSE(ctx context.Context, endpoint string, opts *ClientOptions, elapsed time.Duration, httpErr error) (*Client, error) {
+	sseOpts := *opts
+	if opts.StartupTimeout > 0 {
+		sseOpts.StartupTimeout -= elapsed
+		if sseOpts.StartupTimeout <= 0 {
+			return nil, fmt.Errorf("%w; no time left to fall back to SSE: %w after %s", httpErr, ErrStartupTimeout, opts.StartupTimeout)
+		}
 	}
 
+	client, err := NewSSEClient(ctx, endpoint, &sseOpts)
+	if err != nil {
+		return nil, fmt.Errorf("%w; falling back to SSE also failed: %w", httpErr, err)
+	}
 	return client, nil
 }
 
-// NewClientFromConfig creates an MCP client from a server configuration.
-// The configDir is used to resolve relative paths in the configuration (e.g., envFile).
-func NewClientFromConfig(ctx context.Context, srv *config.ServerConfig, configDir string) (*Client, error) {
+// NewSSEClient creates an MCP client that connects to the given URL using the
+// legacy HTTP+SSE transport (protocol revision 2024-11-05).
+// Pass nil for opts if no options are needed.
+func NewSSEClient(ctx context.Context, endpoint string, opts *ClientOptions) (*Client, error) {
+	httpClient := newHTTPTransportClient(opts)
+	// The SSE stream is a long-lived GET; a whole-request timeout would sever
+	// it mid-session. Connection setup is still bounded by ctx.
+	httpClient.Timeout = 0
+	recorder := &postStatusRecorder{base: httpClient.Transport}
+	httpClient.Transport = recorder
+
+	transport := &mcp.SSEClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: httpClient,
+	}
+
+	session, stop, err := connect(ctx, transport, opts)
+	if err != nil {
+		return nil, recorder.withStatus(fmt.Errorf("failed to connect to MCP server at %s over SSE: %w", endpoint, err))
+	}
+
+	return newClient(session, stop, opts), nil
+}
+
+// NewClientFromConfig creates an MCP client from a server configuration,
+// retrying failed connection attempts according to opts.Retry. If more than
+// one attempt was made, a failure is returned as a *RetryError.
+// Relative paths in the configuration (e.g., envFile) are resolved against the
+// directory of the server's config file.
+// Per-server fields of opts (Name, Env, Headers, Dir) are always taken from srv,
+// and srv's startup and request timeouts override opts when set; the remaining
+// fields apply to every server. Pass nil for opts to use the defaults.
+func NewClientFromConfig(ctx context.Context, srv *config.ServerConfig, opts *ClientOptions) (*Client, error) {
 	if srv == nil {
 		return nil, errors.New("server configuration is nil")
 	}
 
 	// Resolve environment variables
-	env, err := config.MergeServerEnv(srv, configDir)
+	env, err := config.MergeServerEnv(srv, srv.Dir())
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve environment: %w", err)
 	}
 
-	opts := &ClientOptions{
-		Name:    srv.Name,
-		Env:     env,
-		Headers: srv.Headers,
+	clientOpts := &ClientOptions{}
+	if opts != nil {
+		*clientOpts = *opts
+	}
+	clientOpts.Name = srv.Name
+	clientOpts.Env = env
+	clientOpts.Headers = srv.Headers
+	clientOpts.Dir = srv.Cwd
+	if srv.Cwd != "" && !filepath.IsAbs(srv.Cwd) {
+		clientOpts.Dir = filepath.Join(srv.Dir(), srv.Cwd)
+	}
+	if srv.StartupTimeout > 0 {
+		clientOpts.StartupTimeout = time.Duration(srv.StartupTimeout)
+	}
+	if srv.RequestTimeout > 0 {
+		clientOpts.RequestTimeout = time.Duration(srv.RequestTimeout)
 	}
 
+	var client *Client
+	retries, err := clientOpts.Retry.Do(ctx, func() error {
+		var err error
+		client, err = newClientForTransport(ctx, srv, clientOpts)
+		return err
+	})
+	if err != nil {
+		if retries > 0 {
+			return nil, &RetryError{Attempts: retries + 1, Err: err}
+		}
+		return nil, err
+	}
+
+	client.retries.Add(int64(retries))
+	return client, nil
+}
+
+// newClientForTransport makes a single connection attempt using the
+// constructor for the server's transport.
+func newClientForTransport(ctx context.Context, srv *config.ServerConfig, opts *ClientOptions) (*Client, error) {
 	switch srv.Type {
 	case config.TransportStdio:
 		return NewStdioClient(ctx, srv.Command, srv.Args, opts)
 	case config.TransportHTTP:
 		return NewHTTPClient(ctx, srv.URL, opts)
+	case config.TransportSSE:
+		return NewSSEClient(ctx, srv.URL, opts)
 	default:
 		return nil, fmt.Errorf("unsupported transport type: %s", srv.Type)
 	}
@@ -161,5 +474,9 @@ func NewClientFromConfig(ctx context.Context, srv *config.ServerConfig, configDi
 
 // Close terminates the MCP client session.
 func (c *Client) Close() error {
-	return c.ClientSession.Close()
+	err := c.ClientSession.Close()
+	if c.stop != nil {
+		c.stop()
+	}
+	return err
 }
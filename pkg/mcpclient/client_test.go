@@ -0,0 +1,329 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tjhop/mcp-token-analyzer/pkg/config"
+)
+
+// helperEnv selects what the test binary does when re-executed as a stdio
+// server by TestHelperProcess.
+const helperEnv = "MCPCLIENT_TEST_HELPER"
+
+// TestHelperProcess is not a real test: it runs as the stdio server of the
+// tests below when the test binary is re-executed with helperEnv set.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		t.Skip("only runs as a helper process")
+	case "hang":
+		// Never answer initialize, but record the PID so the test can
+		// check the process is gone, and exit once stdin is closed.
+		if err := os.WriteFile(os.Getenv("MCPCLIENT_TEST_PIDFILE"), []byte(strconv.Itoa(os.Getpid())), 0o600); err != nil {
+			os.Exit(2)
+		}
+		_, _ = io.Copy(io.Discard, os.Stdin)
+	case "cwd":
+		// Report the working directory as the server's instructions.
+		cwd, err := os.Getwd()
+		if err != nil {
+			os.Exit(2)
+		}
+		server := mcp.NewServer(&mcp.Implementation{Name: "helper"}, &mcp.ServerOptions{Instructions: cwd})
+		_ = server.Run(context.Background(), &mcp.StdioTransport{})
+	case "list-error":
+		// Log to stderr and fail every tools/list request.
+		server := mcp.NewServer(&mcp.Implementation{Name: "helper"}, nil)
+		mcp.AddTool(server, &mcp.Tool{Name: "echo"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+		server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+			return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+				if method == "tools/list" {
+					fmt.Fprintln(os.Stderr, "database connection lost")
+					return nil, errors.New("internal error")
+				}
+				return next(ctx, method, req)
+			}
+		})
+		_ = server.Run(context.Background(), &mcp.StdioTransport{})
+	}
+	os.Exit(0)
+}
+
+// helperArgs returns the arguments that re-execute the test binary as
+// TestHelperProcess.
+func helperArgs() []string {
+	return []string{"-test.run=^TestHelperProcess$"}
+}
+
+func TestNewStdioClient_StartupTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	start := time.Now()
+	_, err := NewStdioClient(context.Background(), os.Args[0], helperArgs(), &ClientOptions{
+		Env:            map[string]string{helperEnv: "hang", "MCPCLIENT_TEST_PIDFILE": pidFile},
+		StartupTimeout: 500 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrStartupTimeout) {
+		t.Fatalf("expected ErrStartupTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the startup timeout to end the connection promptly, took %s", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("helper process did not start: %v", err)
+	}
+	pid, err := strconv.Atoi(string(data))
+	if err != nil {
+		t.Fatalf("invalid pid file: %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return // Signal 0 cannot probe processes on Windows.
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for proc.Signal(syscall.Signal(0)) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected hung server process %d to be stopped", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewClientFromConfig_RelativeCwd(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	workDir := filepath.Join(dir, "work")
+	if err := os.Mkdir(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &config.ServerConfig{
+		Name:    "helper",
+		Type:    config.TransportStdio,
+		Command: os.Args[0],
+		Args:    helperArgs(),
+		Env:     map[string]string{helperEnv: "cwd"},
+		Cwd:     "work",
+		Source:  config.Source{File: filepath.Join(dir, "mcp.json")},
+	}
+
+	ctx := context.Background()
+	client, err := NewClientFromConfig(ctx, srv, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	got := client.InitializeResult().Instructions
+	if !strings.EqualFold(got, workDir) {
+		t.Errorf("expected cwd relative to the config file %q, got %q", workDir, got)
+	}
+}
+
+func TestClient_WithStderr(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewStdioClient(ctx, os.Args[0], helperArgs(), &ClientOptions{
+		Env: map[string]string{helperEnv: "list-error"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	for _, err = range client.Tools(ctx, nil) {
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t.Fatal("expected listing tools to fail")
+	}
+
+	// stderr is copied asynchronously, so it may trail the response.
+	want := "server stderr:\ndatabase connection lost"
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(client.WithStderr(err).Error(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the listing error to include the server's stderr, got: %v", client.WithStderr(err))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if client.WithStderr(nil) != nil {
+		t.Error("expected a nil error to stay nil")
+	}
+}
+
+// newLegacySSEServer starts a server that only speaks the legacy HTTP+SSE
+// transport and answers POSTs to its endpoint, as a streamable HTTP client
+// sends them, with 405 Method Not Allowed.
+func newLegacySSEServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "legacy"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo the input"},
+		func(context.Context, *mcp.CallToolRequest, struct{ Text string }) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+	sse := mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return server }, nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Query().Get("sessionid") == "" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sse.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// toolNames lists the names of the tools of the client's server.
+func toolNames(t *testing.T, client *Client) []string {
+	t.Helper()
+
+	var names []string
+	for tool, err := range client.Tools(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("failed to list tools: %v", err)
+		}
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestNewSSEClient(t *testing.T) {
+	ts := newLegacySSEServer(t)
+
+	client, err := NewSSEClient(context.Background(), ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	if names := toolNames(t, client); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("expected tool echo, got %v", names)
+	}
+}
+
+func TestNewHTTPClient_SSEFallback(t *testing.T) {
+	ts := newLegacySSEServer(t)
+
+	t.Run("enabled", func(t *testing.T) {
+		client, err := NewHTTPClient(context.Background(), ts.URL, &ClientOptions{SSEFallback: true})
+		if err != nil {
+			t.Fatalf("expected fallback to the SSE transport, got error: %v", err)
+		}
+		defer client.Close()
+
+		if names := toolNames(t, client); len(names) != 1 || names[0] != "echo" {
+			t.Errorf("expected tool echo, got %v", names)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client, err := NewHTTPClient(context.Background(), ts.URL, nil)
+		if err == nil {
+			_ = client.Close()
+			t.Fatal("expected the rejected initialize request to fail the connection")
+		}
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected a 405 StatusError, got %v", err)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		var gets atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				gets.Add(1)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}))
+		defer ts.Close()
+
+		_, err := NewHTTPClient(context.Background(), ts.URL, &ClientOptions{SSEFallback: true})
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected a 401 StatusError, got %v", err)
+		}
+		if gets.Load() != 0 {
+			t.Error("expected no fallback to SSE for an authentication failure")
+		}
+	})
+
+	t.Run("fallback_fails", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}))
+		defer ts.Close()
+
+		_, err := NewHTTPClient(context.Background(), ts.URL, &ClientOptions{SSEFallback: true})
+		if err == nil {
+			t.Fatal("expected both transports to fail")
+		}
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected the streamable HTTP error to be kept, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "over SSE") {
+			t.Errorf("expected the SSE error to be included, got %v", err)
+		}
+	})
+
+	t.Run("startup_timeout", func(t *testing.T) {
+		// Reject the POST slowly and never open the SSE stream, so that
+		// both attempts run into the startup timeout.
+		release := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				<-release
+				return
+			}
+			time.Sleep(300 * time.Millisecond)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}))
+		defer ts.Close()
+		defer close(release)
+
+		timeout := 600 * time.Millisecond
+		start := time.Now()
+		_, err := NewHTTPClient(context.Background(), ts.URL, &ClientOptions{SSEFallback: true, StartupTimeout: timeout})
+		if !errors.Is(err, ErrStartupTimeout) {
+			t.Fatalf("expected ErrStartupTimeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > timeout+200*time.Millisecond {
+			t.Errorf("expected the fallback to share the %s startup timeout, took %s", timeout, elapsed)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// NewInMemoryClient creates an MCP client connected to server through the
+// SDK's in-memory transport, without starting a process or listening on a
+// port. Closing the client also closes the server's session. Pass nil for
+// opts if no options are needed.
+func NewInMemoryClient(ctx context.Context, server *mcp.Server, opts *ClientOptions) (*Client, error) {
+	if server == nil {
+		return nil, errors.New("MCP server for in-memory transport cannot be nil")
+	}
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start in-memory MCP server: %w", err)
+	}
+
+	session, stop, err := connect(ctx, clientTransport, opts)
+	if err != nil {
+		_ = serverSession.Close()
+		return nil, fmt.Errorf("failed to connect to in-memory MCP server: %w", err)
+	}
+
+	client := newClient(session, func() {
+		stop()
+		_ = serverSession.Close()
+	}, opts)
+	return client, nil
+}
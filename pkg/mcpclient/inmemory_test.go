@@ -0,0 +1,76 @@
+package mcpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestNewInMemoryClient(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "in-memory"}, &mcp.ServerOptions{Instructions: "Use me."})
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo the input"},
+		func(context.Context, *mcp.CallToolRequest, struct{ Text string }) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+
+	ctx := context.Background()
+	client, err := NewInMemoryClient(ctx, server, &ClientOptions{Name: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	init := client.InitializeResult()
+	if init.ServerInfo.Name != "in-memory" || init.Instructions != "Use me." {
+		t.Errorf("unexpected initialize result: %+v", init)
+	}
+
+	var tools []string
+	for tool, err := range client.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatalf("failed to list tools: %v", err)
+		}
+		tools = append(tools, tool.Name)
+	}
+	if len(tools) != 1 || tools[0] != "echo" {
+		t.Errorf("expected tool echo, got %v", tools)
+	}
+}
+
+func TestNewInMemoryClient_NilServer(t *testing.T) {
+	if _, err := NewInMemoryClient(context.Background(), nil, nil); err == nil {
+		t.Error("expected error for nil server")
+	}
+}
+
+func TestNewInMemoryClient_ListChanged(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "in-memory"}, nil)
+	handler := func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "first"}, handler)
+
+	changed := make(chan struct{}, 1)
+	client, err := NewInMemoryClient(context.Background(), server, &ClientOptions{
+		ListChanged: func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	mcp.AddTool(server, &mcp.Tool{Name: "second"}, handler)
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a list changed notification after adding a tool")
+	}
+}
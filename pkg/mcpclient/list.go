@@ -0,0 +1,112 @@
+package mcpclient
+
+import (
+	"context"
+	"iter"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// paginate returns an iterator over the items of a paginated list request,
+// starting at cursor. Each page request is retried according to the client's
+// retry policy, so one transient failure doesn't cut a listing short.
+func paginate[T any](ctx context.Context, c *Client, cursor string, list func(ctx context.Context, cursor string) ([]*T, string, error)) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for {
+			var (
+				items []*T
+				next  string
+			)
+			retries, err := c.retry.Do(ctx, func() error {
+				var err error
+				items, next, err = list(ctx, cursor)
+				return err
+			})
+			c.retries.Add(int64(retries))
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// Tools returns an iterator over all tools, like ClientSession.Tools, with
+// page requests retried according to the client's retry policy.
+func (c *Client) Tools(ctx context.Context, params *mcp.ListToolsParams) iter.Seq2[*mcp.Tool, error] {
+	p := &mcp.ListToolsParams{}
+	if params != nil {
+		*p = *params
+	}
+	return paginate(ctx, c, p.Cursor, func(ctx context.Context, cursor string) ([]*mcp.Tool, string, error) {
+		p.Cursor = cursor
+		res, err := c.ListTools(ctx, p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Tools, res.NextCursor, nil
+	})
+}
+
+// Prompts returns an iterator over all prompts, like ClientSession.Prompts,
+// with page requests retried according to the client's retry policy.
+func (c *Client) Prompts(ctx context.Context, params *mcp.ListPromptsParams) iter.Seq2[*mcp.Prompt, error] {
+	p := &mcp.ListPromptsParams{}
+	if params != nil {
+		*p = *params
+	}
+	return paginate(ctx, c, p.Cursor, func(ctx context.Context, cursor string) ([]*mcp.Prompt, string, error) {
+		p.Cursor = cursor
+		res, err := c.ListPrompts(ctx, p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Prompts, res.NextCursor, nil
+	})
+}
+
+// Resources returns an iterator over all resources, like
+// ClientSession.Resources, with page requests retried according to the
+// client's retry policy.
+func (c *Client) Resources(ctx context.Context, params *mcp.ListResourcesParams) iter.Seq2[*mcp.Resource, error] {
+	p := &mcp.ListResourcesParams{}
+	if params != nil {
+		*p = *params
+	}
+	return paginate(ctx, c, p.Cursor, func(ctx context.Context, cursor string) ([]*mcp.Resource, string, error) {
+		p.Cursor = cursor
+		res, err := c.ListResources(ctx, p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Resources, res.NextCursor, nil
+	})
+}
+
+// ResourceTemplates returns an iterator over all resource templates, like
+// ClientSession.ResourceTemplates, with page requests retried according to
+// the client's retry policy.
+func (c *Client) ResourceTemplates(ctx context.Context, params *mcp.ListResourceTemplatesParams) iter.Seq2[*mcp.ResourceTemplate, error] {
+	p := &mcp.ListResourceTemplatesParams{}
+	if params != nil {
+		*p = *params
+	}
+	return paginate(ctx, c, p.Cursor, func(ctx context.Context, cursor string) ([]*mcp.ResourceTemplate, string, error) {
+		p.Cursor = cursor
+		res, err := c.ListResourceTemplates(ctx, p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.ResourceTemplates, res.NextCursor, nil
+	})
+}
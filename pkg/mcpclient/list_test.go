@@ -0,0 +1,89 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakePages serves fixed pages of strings, failing the first failures
+// requests with err.
+type fakePages struct {
+	pages    [][]string
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *fakePages) list(_ context.Context, cursor string) ([]*string, string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, "", f.err
+	}
+
+	page := 0
+	if cursor != "" {
+		page = int(cursor[0] - '0')
+	}
+	var items []*string
+	for i := range f.pages[page] {
+		items = append(items, &f.pages[page][i])
+	}
+	next := ""
+	if page+1 < len(f.pages) {
+		next = string(rune('0' + page + 1))
+	}
+	return items, next, nil
+}
+
+func collect(t *testing.T, c *Client, f *fakePages) ([]string, error) {
+	t.Helper()
+
+	var got []string
+	for item, err := range paginate(context.Background(), c, "", f.list) {
+		if err != nil {
+			return got, err
+		}
+		got = append(got, *item)
+	}
+	return got, nil
+}
+
+func TestPaginate_RetriesPages(t *testing.T) {
+	c := &Client{retry: RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}}
+	f := &fakePages{
+		pages:    [][]string{{"a", "b"}, {"c"}},
+		failures: 2,
+		err:      errTransient,
+	}
+
+	got, err := collect(t, c, f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected items %v", got)
+	}
+	if c.Retries() != 2 {
+		t.Errorf("expected 2 retries, got %d", c.Retries())
+	}
+}
+
+func TestPaginate_NoRetryPolicy(t *testing.T) {
+	c := &Client{}
+	f := &fakePages{
+		pages:    [][]string{{"a"}},
+		failures: 1,
+		err:      errTransient,
+	}
+
+	got, err := collect(t, c, f)
+	if !errors.Is(err, errTransient) {
+		t.Fatalf("expected transient error without retries, got %v", err)
+	}
+	if len(got) != 0 || c.Retries() != 0 {
+		t.Errorf("expected no items and no retries, got %v and %d", got, c.Retries())
+	}
+}
@@ -0,0 +1,188 @@
+package mcpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// rawResultRecorder matches the responses read by the client to the
+// requests it wrote, and passes the raw result of each successful one to
+// record, as configured by ClientOptions.RawResults.
+type rawResultRecorder struct {
+	record func(method string, result json.RawMessage)
+
+	mu      sync.Mutex
+	methods map[jsonrpc.ID]string // Methods of requests awaiting a response, by request ID
+}
+
+func newRawResultRecorder(record func(method string, result json.RawMessage)) *rawResultRecorder {
+	return &rawResultRecorder{record: record, methods: make(map[jsonrpc.ID]string)}
+}
+
+// wrote remembers the method of a request written by the client.
+func (r *rawResultRecorder) wrote(msg jsonrpc.Message) {
+	if req, ok := msg.(*jsonrpc.Request); ok && req.IsCall() {
+		r.mu.Lock()
+		r.methods[req.ID] = req.Method
+		r.mu.Unlock()
+	}
+}
+
+// read records the result of a response read by the client.
+func (r *rawResultRecorder) read(msg jsonrpc.Message) {
+	resp, ok := msg.(*jsonrpc.Response)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	method, found := r.methods[resp.ID]
+	delete(r.methods, resp.ID)
+	r.mu.Unlock()
+	if found && resp.Error == nil {
+		r.record(method, resp.Result)
+	}
+}
+
+// rawResultTransport wraps a transport to record the raw results of the
+// client's requests from its connection.
+//
+// Wrapping the connection hides the SDK's unexported session hooks, which
+// the streamable HTTP transport relies on to send the negotiated protocol
+// version and open its standalone SSE stream. Streamable HTTP sessions are
+// therefore observed at the HTTP layer instead, by rawResultRoundTripper.
+type rawResultTransport struct {
+	mcp.Transport
+	recorder *rawResultRecorder
+}
+
+// Connect connects the wrapped transport and wraps its connection.
+func (t *rawResultTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rawResultConn{Connection: conn, recorder: t.recorder}, nil
+}
+
+// rawResultConn passes the messages written to and read from the connection
+// to its recorder.
+type rawResultConn struct {
+	mcp.Connection
+	recorder *rawResultRecorder
+}
+
+func (c *rawResultConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	c.recorder.wrote(msg)
+	return c.Connection.Write(ctx, msg)
+}
+
+func (c *rawResultConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	msg, err := c.Connection.Read(ctx)
+	if err == nil {
+		c.recorder.read(msg)
+	}
+	return msg, err
+}
+
+// rawResultRoundTripper wraps an http.RoundTripper to pass the JSON-RPC
+// messages of a streamable HTTP session to its recorder: requests from the
+// bodies of POSTs, and responses from JSON bodies and event streams.
+type rawResultRoundTripper struct {
+	base     http.RoundTripper
+	recorder *rawResultRecorder
+}
+
+func (r *rawResultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if msg, err := jsonrpc.DecodeMessage(data); err == nil {
+			r.recorder.wrote(msg)
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if msg, err := jsonrpc.DecodeMessage(data); err == nil {
+			r.recorder.read(msg)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+	case "text/event-stream":
+		resp.Body = &eventStreamRecorder{ReadCloser: resp.Body, recorder: r.recorder}
+	}
+	return resp, nil
+}
+
+// eventStreamRecorder passes the JSON-RPC messages of an SSE stream to its
+// recorder as the client reads them.
+type eventStreamRecorder struct {
+	io.ReadCloser
+	recorder *rawResultRecorder
+
+	line []byte // Incomplete line read so far
+	data []byte // Data of the current event
+}
+
+func (e *eventStreamRecorder) Read(p []byte) (int, error) {
+	n, err := e.ReadCloser.Read(p)
+	e.feed(p[:n])
+	return n, err
+}
+
+// feed processes the next chunk of the stream, dispatching every event it
+// completes.
+func (e *eventStreamRecorder) feed(chunk []byte) {
+	for len(chunk) > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			e.line = append(e.line, chunk...)
+			return
+		}
+		e.line = append(e.line, chunk[:i]...)
+		chunk = chunk[i+1:]
+
+		line := bytes.TrimSuffix(e.line, []byte("\r"))
+		switch {
+		case len(line) == 0:
+			if msg, err := jsonrpc.DecodeMessage(e.data); err == nil {
+				e.recorder.read(msg)
+			}
+			e.data = e.data[:0]
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(e.data) > 0 {
+				e.data = append(e.data, '\n')
+			}
+			value := bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			e.data = append(e.data, value...)
+		}
+		e.line = e.line[:0]
+	}
+}
@@ -0,0 +1,129 @@
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestClientOptions_RawResults(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "in-memory"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo the input"},
+		func(context.Context, *mcp.CallToolRequest, struct{ Text string }) (*mcp.CallToolResult, any, error) {
+			return nil, nil, nil
+		})
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string][]json.RawMessage)
+	)
+	ctx := context.Background()
+	client, err := NewInMemoryClient(ctx, server, &ClientOptions{
+		RawResults: func(method string, result json.RawMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			results[method] = append(results[method], result)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	for _, err := range client.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatalf("failed to list tools: %v", err)
+		}
+	}
+	if _, err := client.GetPrompt(ctx, &mcp.GetPromptParams{Name: "missing"}); err == nil {
+		t.Fatal("expected getting an unknown prompt to fail")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results["initialize"]) != 1 {
+		t.Errorf("expected the initialize result to be recorded once, got %d", len(results["initialize"]))
+	}
+	tools := results["tools/list"]
+	if len(tools) != 1 || !strings.Contains(string(tools[0]), `"name":"echo"`) {
+		t.Errorf("expected the raw tools/list result, got %s", tools)
+	}
+	if _, ok := results["prompts/get"]; ok {
+		t.Error("expected failed requests not to be recorded")
+	}
+}
+
+func TestClientOptions_RawResultsStreamableHTTP(t *testing.T) {
+	for _, jsonResponse := range []bool{false, true} {
+		name := "event stream"
+		if jsonResponse {
+			name = "json"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := mcp.NewServer(&mcp.Implementation{Name: "http"}, nil)
+			mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo the input"},
+				func(context.Context, *mcp.CallToolRequest, struct{ Text string }) (*mcp.CallToolResult, any, error) {
+					return nil, nil, nil
+				})
+			handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server },
+				&mcp.StreamableHTTPOptions{JSONResponse: jsonResponse})
+
+			var (
+				mu       sync.Mutex
+				versions []string // Protocol version header of each POST after initialize
+				results  = make(map[string][]json.RawMessage)
+			)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && r.Header.Get("Mcp-Session-Id") != "" {
+					mu.Lock()
+					versions = append(versions, r.Header.Get("Mcp-Protocol-Version"))
+					mu.Unlock()
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			ctx := context.Background()
+			client, err := NewHTTPClient(ctx, ts.URL, &ClientOptions{
+				RawResults: func(method string, result json.RawMessage) {
+					mu.Lock()
+					defer mu.Unlock()
+					results[method] = append(results[method], result)
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer client.Close()
+
+			for _, err := range client.Tools(ctx, nil) {
+				if err != nil {
+					t.Fatalf("failed to list tools: %v", err)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			tools := results["tools/list"]
+			if len(tools) != 1 || !strings.Contains(string(tools[0]), `"name":"echo"`) {
+				t.Errorf("expected the raw tools/list result, got %s", tools)
+			}
+			if len(versions) == 0 {
+				t.Fatal("expected requests after initialize")
+			}
+			// Recording must not hide the negotiated protocol version.
+			for _, v := range versions {
+				if v == "" {
+					t.Errorf("expected every request after initialize to carry the protocol version, got %q", versions)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// codeTransportRejected is the JSON-RPC error code the SDK uses when the
+// transport rejects a message. The streamable HTTP transport returns it for
+// transient HTTP statuses (429, 502, 503, 504).
+const codeTransportRejected = -32005
+
+// RetryPolicy controls how failed connection attempts and list requests are
+// retried. The zero value disables retries.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	Attempts int
+
+	// InitialBackoff is the delay before the first retry. Each further
+	// retry doubles the delay, up to MaxBackoff (if set).
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Jitter randomizes each delay by up to this fraction in either
+	// direction (e.g. 0.2 for +/-20%) so that parallel connections to the
+	// same host don't retry in lockstep.
+	Jitter float64
+
+	// Retryable classifies errors. If nil, IsRetryable is used.
+	Retryable func(error) bool
+}
+
+// backoff returns the delay before the given retry (1 for the first retry).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	return delay
+}
+
+// retryable reports whether err should be retried under the policy.
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// the attempts are exhausted, sleeping between attempts. It returns the
+// number of retries made and the error of the last attempt. Waiting stops
+// early if ctx is done.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !p.retryable(err) {
+			return attempt - 1, err
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt - 1, err
+		case <-timer.C:
+		}
+	}
+}
+
+// RetryError is returned by NewClientFromConfig when a connection failed
+// after more than one attempt.
+type RetryError struct {
+	Attempts int
+	Err      error // Error of the last attempt
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError records the HTTP status code a server answered a failed
+// request with. HTTP-based clients attach it to connection errors.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsRetryable reports whether err looks transient: rate limiting and
+// gateway/availability HTTP statuses (408, 429, 502, 503, 504), transport
+// rejections, and network errors such as refused or reset connections.
+// Cancellation, expired deadlines (such as request timeouts), startup
+// timeouts and errors reported by the server itself are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrStartupTimeout) {
+		return false
+	}
+	// context.DeadlineExceeded implements net.Error; check it first.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var wireErr *jsonrpc.Error
+	if errors.As(err, &wireErr) {
+		return wireErr.Code == codeTransportRejected
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
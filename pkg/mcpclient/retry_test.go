@@ -0,0 +1,144 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// errTransient is a retryable error for tests.
+var errTransient = &StatusError{StatusCode: 503}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name        string
+		errs        []error // returned by successive attempts; nil after the last
+		wantRetries int
+		wantCalls   int
+		wantErr     bool
+	}{
+		{
+			name:      "success",
+			wantCalls: 1,
+		},
+		{
+			name:        "transient_then_success",
+			errs:        []error{errTransient, errTransient},
+			wantRetries: 2,
+			wantCalls:   3,
+		},
+		{
+			name:        "attempts_exhausted",
+			errs:        []error{errTransient, errTransient, errTransient, errTransient},
+			wantRetries: 2,
+			wantCalls:   3,
+			wantErr:     true,
+		},
+		{
+			name:      "not_retryable",
+			errs:      []error{errors.New("invalid params")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			retries, err := policy.Do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if retries != tt.wantRetries || calls != tt.wantCalls {
+				t.Errorf("got %d retries and %d calls, want %d and %d", retries, calls, tt.wantRetries, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_DoZeroValue(t *testing.T) {
+	var calls int
+	retries, err := RetryPolicy{}.Do(context.Background(), func() error {
+		calls++
+		return errTransient
+	})
+	if retries != 0 || calls != 1 || err == nil {
+		t.Errorf("expected the zero policy not to retry, got %d retries, %d calls, err %v", retries, calls, err)
+	}
+}
+
+func TestRetryPolicy_DoStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	policy := RetryPolicy{Attempts: 5, InitialBackoff: time.Hour}
+	var calls int
+	retries, err := policy.Do(ctx, func() error {
+		calls++
+		return errTransient
+	})
+	if retries != 0 || calls != 1 || !errors.Is(err, errTransient) {
+		t.Errorf("expected to stop after the first attempt, got %d retries, %d calls, err %v", retries, calls, err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 100 {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jittered backoff %v outside +/-50%% of 100ms", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"service_unavailable", fmt.Errorf("connect: %w", &StatusError{StatusCode: 503}), true},
+		{"too_many_requests", &StatusError{StatusCode: 429}, true},
+		{"unauthorized", &StatusError{StatusCode: 401}, false},
+		{"transport_rejected", fmt.Errorf("calling initialize: %w", &jsonrpc.Error{Code: codeTransportRejected}), true},
+		{"method_not_found", &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound}, false},
+		{"connection_refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"connection_reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unexpected_eof", io.ErrUnexpectedEOF, true},
+		{"canceled", fmt.Errorf("connect: %w", context.Canceled), false},
+		{"deadline_exceeded", fmt.Errorf("calling \"tools/list\": %w", context.DeadlineExceeded), false},
+		{"startup_timeout", fmt.Errorf("connect: %w", ErrStartupTimeout), false},
+		{"process_exited", io.EOF, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
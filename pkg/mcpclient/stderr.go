@@ -0,0 +1,70 @@
+package mcpclient
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits for the stderr output kept from stdio servers.
+const (
+	stderrBufferSize = 8 << 10 // bytes kept in memory per server
+	stderrTailLines  = 20      // lines included in connection errors
+
+	// stderrWaitDelay bounds how long to wait for stderr to be drained
+	// after a stdio server exits.
+	stderrWaitDelay = time.Second
+)
+
+// ringBuffer is an io.Writer that keeps only the last size bytes written to
+// it. It is safe for concurrent use.
+type ringBuffer struct {
+	mu      sync.Mutex
+	buf     []byte
+	size    int
+	dropped bool // Whether earlier output was discarded
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{size: size}
+}
+
+// Write implements io.Writer. It never fails.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(p)
+	if len(p) >= r.size {
+		p = p[len(p)-r.size:]
+		r.buf = r.buf[:0]
+		r.dropped = true
+	} else if overflow := len(r.buf) + len(p) - r.size; overflow > 0 {
+		r.buf = r.buf[:copy(r.buf, r.buf[overflow:])]
+		r.dropped = true
+	}
+	r.buf = append(r.buf, p...)
+
+	return n, nil
+}
+
+// Tail returns up to n of the last complete or partial lines written, with
+// surrounding whitespace trimmed. If output was discarded, a partial first
+// line is dropped.
+func (r *ringBuffer) Tail(n int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	text := string(r.buf)
+	if r.dropped {
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
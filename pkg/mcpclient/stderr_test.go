@@ -0,0 +1,78 @@
+package mcpclient
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_Tail(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		lines  int
+		want   string
+	}{
+		{
+			name: "empty",
+			size: 64,
+			want: "",
+		},
+		{
+			name:   "fits",
+			size:   64,
+			writes: []string{"starting\n", "error: boom\n"},
+			lines:  10,
+			want:   "starting\nerror: boom",
+		},
+		{
+			name:   "line_limit",
+			size:   64,
+			writes: []string{"one\ntwo\nthree\nfour\n"},
+			lines:  2,
+			want:   "three\nfour",
+		},
+		{
+			name:   "overflow_drops_partial_line",
+			size:   12,
+			writes: []string{"first line\n", "second\n", "third\n"},
+			lines:  10,
+			want:   "third",
+		},
+		{
+			name:   "single_write_larger_than_buffer",
+			size:   8,
+			writes: []string{"aaaaaaaaaa\nbbbbb\n"},
+			lines:  10,
+			want:   "bbbbb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRingBuffer(tt.size)
+			for _, w := range tt.writes {
+				if n, err := r.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := r.Tail(tt.lines); got != tt.want {
+				t.Errorf("Tail(%d) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Bounded(t *testing.T) {
+	r := newRingBuffer(100)
+	for i := range 1000 {
+		fmt.Fprintf(r, "line %d\n", i)
+	}
+
+	if len(r.buf) > 100 {
+		t.Errorf("buffer grew to %d bytes, want at most 100", len(r.buf))
+	}
+	if !strings.HasSuffix(r.Tail(1), "line 999") {
+		t.Errorf("expected last line to be kept, got %q", r.Tail(1))
+	}
+}